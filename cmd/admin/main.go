@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/database"
+	"github.com/isd-sgcu/rpkm67-backend/internal/admin"
+	"github.com/isd-sgcu/rpkm67-backend/internal/allocation"
+	"github.com/isd-sgcu/rpkm67-backend/internal/baan"
+	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"github.com/isd-sgcu/rpkm67-backend/internal/user"
+	"github.com/isd-sgcu/rpkm67-backend/logger"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: admin -staff <staff user id> <command> [flags]
+
+commands:
+  move -user <id> -group <id>            move a user into another group
+  merge -source <id> -target <id>        move every member of source into target and delete source
+  split -group <id> -users <id,id,...>   move users into a new group led by the first of them
+  confirm -group <id> [-unconfirm]       force confirm or unconfirm a group
+  delete-orphans                         delete groups that no user belongs to
+  events -group <id> | -user <id> [-page <n>] [-page-size <n>]
+                                         page through the history of a group or a user, newest first
+  window-override [-off]                 keep the selection window open past its close time, or stop doing so
+  allocate -dry-run [-seed <n>]          report how many groups get each of their choices without saving it
+  allocate -seed <n>                     persist the allocation of the dry run that reported seed n
+`
+
+// Staff tool for repairing broken groups and reading their history. Every change is recorded in the group history
+// under the given staff id, which must belong to a user with the staff role.
+//
+// The tool does not authenticate its operator: -staff is self-reported, and anyone who can run it can act as any
+// staff member. Access to the database credentials it loads is the real trust boundary, so only hand those to staff,
+// and read the recorded staff id as a claim of who ran the command rather than proof.
+func main() {
+	staffId := flag.String("staff", "", "id of the staff user making the change")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if *staffId == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conf, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	logger := logger.New(conf)
+
+	db, err := database.InitDatabase(&conf.Db, conf.App.IsDevelopment())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to connect to database: %v", err))
+	}
+
+	redis, err := database.InitRedis(&conf.Redis)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to connect to redis: %v", err))
+	}
+
+	// the local tier publishes invalidations, which replicas keeping a local copy rely on. The admin
+	// only invalidates, so it needs no codecs for the cached values.
+	cacheRepo := cache.NewRepository(redis, nil, &conf.Cache)
+	if conf.Cache.LocalSize > 0 {
+		cacheRepo = cache.NewLocalRepository(cacheRepo, redis, nil, &conf.Cache, logger.Named("localCache"))
+	}
+	cacheAside := cache.NewAside(cacheRepo, nil, &conf.Cache, nil, logger.Named("cache"))
+
+	baanRepo, err := baan.NewRepository(&conf.Baan)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load baan registry: %v", err))
+	}
+	allocationSvc := allocation.NewService(allocation.NewRepository(db), baanRepo, logger.Named("allocationSvc"))
+
+	adminSvc := admin.NewService(group.NewRepository(db), user.NewRepository(db), allocationSvc, cacheAside, &conf.Group, logger.Named("adminSvc"))
+
+	ctx := context.Background()
+	cmd := flag.NewFlagSet(flag.Arg(0), flag.ExitOnError)
+	var res interface{}
+
+	switch flag.Arg(0) {
+	case "move":
+		userId := cmd.String("user", "", "user id")
+		groupId := cmd.String("group", "", "target group id")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.MoveUser(ctx, &dto.MoveUserAdminRequest{StaffId: *staffId, UserId: *userId, GroupId: *groupId})
+	case "merge":
+		source := cmd.String("source", "", "group id to merge and delete")
+		target := cmd.String("target", "", "group id to merge into")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.MergeGroups(ctx, &dto.MergeGroupsAdminRequest{StaffId: *staffId, SourceGroupId: *source, TargetGroupId: *target})
+	case "split":
+		groupId := cmd.String("group", "", "group id to split")
+		userIds := cmd.String("users", "", "comma separated user ids to move, the first leads the new group")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.SplitGroup(ctx, &dto.SplitGroupAdminRequest{StaffId: *staffId, GroupId: *groupId, UserIds: strings.Split(*userIds, ",")})
+	case "confirm":
+		groupId := cmd.String("group", "", "group id")
+		unconfirm := cmd.Bool("unconfirm", false, "unconfirm instead of confirm")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.UpdateConfirm(ctx, &dto.UpdateConfirmAdminRequest{StaffId: *staffId, GroupId: *groupId, IsConfirmed: !*unconfirm})
+	case "delete-orphans":
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.DeleteOrphanGroups(ctx, &dto.DeleteOrphanGroupsAdminRequest{StaffId: *staffId})
+	case "events":
+		groupId := cmd.String("group", "", "group id")
+		userId := cmd.String("user", "", "user id")
+		page := cmd.Int("page", 1, "page number, starting at 1")
+		pageSize := cmd.Int("page-size", 0, "events per page, 0 for the default")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.FindEvents(ctx, &dto.FindEventsAdminRequest{StaffId: *staffId, GroupId: *groupId, UserId: *userId, Page: *page, PageSize: *pageSize})
+	case "window-override":
+		off := cmd.Bool("off", false, "stop overriding the window")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.SetWindowOverride(ctx, &dto.SetWindowOverrideAdminRequest{StaffId: *staffId, Override: !*off})
+	case "allocate":
+		seed := cmd.Int64("seed", 0, "seed for the allocation shuffle, required unless -dry-run")
+		dryRun := cmd.Bool("dry-run", false, "report the result without saving it")
+		cmd.Parse(flag.Args()[1:])
+		res, err = adminSvc.Allocate(ctx, &dto.AllocateAdminRequest{StaffId: *staffId, Seed: *seed, DryRun: *dryRun})
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		logger.Fatal("Admin command failed", zap.String("command", flag.Arg(0)), zap.Error(err))
+	}
+
+	out, _ := json.MarshalIndent(res, "", "  ")
+	fmt.Println(string(out))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -13,6 +14,8 @@ import (
 	"github.com/isd-sgcu/rpkm67-backend/config"
 	"github.com/isd-sgcu/rpkm67-backend/constant"
 	"github.com/isd-sgcu/rpkm67-backend/database"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
+	"github.com/isd-sgcu/rpkm67-backend/internal/baan"
 	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
 	"github.com/isd-sgcu/rpkm67-backend/internal/count"
 	"github.com/isd-sgcu/rpkm67-backend/internal/group"
@@ -20,6 +23,8 @@ import (
 	"github.com/isd-sgcu/rpkm67-backend/internal/selection"
 	"github.com/isd-sgcu/rpkm67-backend/internal/stamp"
 	"github.com/isd-sgcu/rpkm67-backend/internal/user"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	"github.com/isd-sgcu/rpkm67-backend/internal/window"
 	"github.com/isd-sgcu/rpkm67-backend/logger"
 	countProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/count/v1"
 	groupProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
@@ -27,8 +32,11 @@ import (
 	selectionProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
 	stampProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/stamp/v1"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
@@ -52,21 +60,47 @@ func main() {
 		panic(fmt.Sprintf("Failed to connect to redis: %v", err))
 	}
 
-	cacheRepo := cache.NewRepository(redis)
+	baanRepo, err := baan.NewRepository(&conf.Baan)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load baan registry: %v", err))
+	}
+
+	groupCodec := cache.Compress(cache.JSONCodec{}, cache.Compression(conf.Cache.Compression), conf.Cache.CompressAbove)
+	cacheCodecs := cache.Codecs{
+		group.GroupByUserIdKey(""): groupCodec,
+		group.GroupByTokenKey(""):  groupCodec,
+		selection.CountByBaanIdKey: cache.ProtoCodec{},
+	}
+	cacheRepo := cache.NewRepository(redis, cacheCodecs, &conf.Cache)
+	if conf.Cache.LocalSize > 0 {
+		cacheRepo = cache.NewLocalRepository(cacheRepo, redis, cacheCodecs, &conf.Cache, logger.Named("localCache"))
+	}
+	cacheMetrics := cache.NewMetrics(prometheus.DefaultRegisterer)
+	cacheAside := cache.NewAside(cacheRepo, cacheCodecs, &conf.Cache, cacheMetrics, logger.Named("cache"))
 
-	pinRepo := pin.NewRepository(redis)
+	pinRepo := pin.NewRepository(redis, &conf.Pin)
 	pinUtils := pin.NewUtils()
 	pinSvc := pin.NewService(&conf.Pin, pinUtils, pinRepo, logger.Named("pinSvc"))
 
 	stampRepo := stamp.NewRepository(db)
 	stampSvc := stamp.NewService(stampRepo, constant.ActivityIdToIdx, logger.Named("stampSvc"))
 
+	selectionWindow := window.NewWindow(&conf.Selection)
+
 	userRepo := user.NewRepository(db)
 	groupRepo := group.NewRepository(db)
-	groupSvc := group.NewService(groupRepo, userRepo, cacheRepo, &conf.Group, logger.Named("groupSvc"))
-
 	selectionRepo := selection.NewRepository(db)
-	selectionSvc := selection.NewService(selectionRepo, groupRepo, cacheRepo, &conf.Selection, logger.Named("selectionSvc"))
+	groupSvc := group.NewService(groupRepo, userRepo, selectionRepo, cacheAside, selectionWindow, &conf.Group, logger.Named("groupSvc"))
+
+	selectionSvc := selection.NewService(selectionRepo, groupRepo, baanRepo, cacheAside, selectionWindow, &conf.Selection, logger.Named("selectionSvc"))
+
+	lockGroups := func() {
+		if err := groupSvc.LockAll(context.Background()); err != nil {
+			logger.Error("Failed to lock groups after selection window closed", zap.Error(err))
+		}
+	}
+	stopSync := syncWindow(selectionWindow, groupRepo, lockGroups, logger.Named("window"))
+	stopLock := window.OnClose(selectionWindow, lockGroups)
 
 	countRepo := count.NewRepository(db)
 	countSvc := count.NewService(countRepo, logger.Named("countSvc"))
@@ -76,12 +110,22 @@ func main() {
 		panic(fmt.Sprintf("Failed to listen: %v", err))
 	}
 
-	grpcServer := grpc.NewServer()
+	if conf.Auth.Secret == "" {
+		panic("AUTH_SECRET must be set")
+	}
+
+	// the JSON services take their dto messages in this codec
+	encoding.RegisterCodec(utils.JSONCodec{})
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.NewUnaryInterceptor(&conf.Auth, auth.Policies, logger.Named("auth"))),
+	)
 	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
 	pinProto.RegisterPinServiceServer(grpcServer, pinSvc)
 	stampProto.RegisterStampServiceServer(grpcServer, stampSvc)
 	groupProto.RegisterGroupServiceServer(grpcServer, groupSvc)
+	group.RegisterGroupJSONServiceServer(grpcServer, groupSvc)
 	selectionProto.RegisterSelectionServiceServer(grpcServer, selectionSvc)
+	selection.RegisterSelectionJSONServiceServer(grpcServer, selectionSvc)
 	countProto.RegisterCountServiceServer(grpcServer, countSvc)
 
 	reflection.Register(grpcServer)
@@ -93,11 +137,30 @@ func main() {
 		}
 	}()
 
+	metricsServer := &http.Server{Addr: fmt.Sprintf(":%v", conf.App.MetricsPort), Handler: promhttp.Handler()}
+	if conf.App.MetricsPort != "" {
+		go func() {
+			logger.Sugar().Infof("Metrics starting at port %v", conf.App.MetricsPort)
+
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
 	wait := gracefulShutdown(context.Background(), 2*time.Second, logger, map[string]operation{
 		"server": func(ctx context.Context) error {
 			grpcServer.GracefulStop()
 			return nil
 		},
+		"metrics": func(ctx context.Context) error {
+			return metricsServer.Shutdown(ctx)
+		},
+		"window": func(ctx context.Context) error {
+			stopSync()
+			stopLock()
+			return nil
+		},
 		"database": func(ctx context.Context) error {
 			sqlDB, err := db.DB()
 			if err != nil {
@@ -118,6 +181,43 @@ func main() {
 	logger.Info("RPKM67 Backend service has been shutdown gracefully")
 }
 
+const windowSyncInterval = 10 * time.Second
+
+// syncWindow applies the override staff set through the admin tool, now and then every interval, as
+// every replica reads it from the window state. Groups are locked while the window is closed and not
+// overridden, which also covers lifting the override after the close; LockAll runs only once.
+func syncWindow(w window.Window, repo group.Repository, lockGroups func(), log *zap.Logger) (stop func()) {
+	apply := func() {
+		state := &group.WindowState{}
+		if err := repo.FindWindowState(state); err != nil {
+			log.Error("FindWindowState: ", zap.Error(err))
+			return
+		}
+
+		w.SetOverride(state.Override)
+		if w.IsClosed() && !state.Override && state.LockedAt == nil {
+			lockGroups()
+		}
+	}
+	apply()
+
+	ticker := time.NewTicker(windowSyncInterval)
+	done := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				apply()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
 type operation func(ctx context.Context) error
 
 func gracefulShutdown(ctx context.Context, timeout time.Duration, log *zap.Logger, ops map[string]operation) <-chan struct{} {
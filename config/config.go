@@ -1,15 +1,20 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/isd-sgcu/rpkm67-backend/constant"
 	"github.com/joho/godotenv"
 )
 
 type AppConfig struct {
-	Port string
-	Env  string
+	Port        string
+	MetricsPort string // serves /metrics over http, empty to disable
+	Env         string
 }
 
 type DbConfig struct {
@@ -23,12 +28,31 @@ type RedisConfig struct {
 }
 
 type GroupConfig struct {
+	Capacity         int // max members, unless the members' cohort allows a different size
+	MinSize          int // members needed to confirm, 0 for no minimum
+	CohortCapacities []CohortCapacity
+	CacheTTL         int
+	LeaderSuccession bool
+	JoinApproval     bool
+	JoinRequestTTL   int
+	SelectionPolicy  constant.SelectionPolicy
+}
+
+// CohortCapacity sets the group size for users of a faculty and year
+type CohortCapacity struct {
+	Faculty  string // empty matches every faculty
+	Year     int    // 0 matches every year
 	Capacity int
-	CacheTTL int
 }
 
 type SelectionConfig struct {
 	CacheTTL int
+	OpenAt   time.Time
+	CloseAt  time.Time
+}
+
+type BaanConfig struct {
+	SeedFile string
 }
 
 type PinConfig struct {
@@ -36,14 +60,37 @@ type PinConfig struct {
 	WorkshopCount int
 	LandmarkCode  string
 	LandmarkCount int
+	Timeout       int // milliseconds a Redis call may take, 0 for the default
+	// Period is the seconds a rotating pin lasts, 0 for static pins that change only on reset.
+	// Switching between the two replaces every stored pin, so codes handed out before stop matching.
+	Period int
+}
+
+type AuthConfig struct {
+	Secret  string
+	MaxSkew int
+}
+
+type CacheConfig struct {
+	Timeout          int     // milliseconds a Redis call may take, 0 for the default
+	LockTTL          int     // seconds a loader holds the refill lock, 0 for the default
+	EarlyRefreshBeta float64 // how eagerly entries are refreshed before they expire, 0 to disable
+	LocalSize        int     // entries kept in memory in front of Redis, 0 to disable
+	LocalTTL         int     // longest seconds an entry is kept in memory, 0 for the default
+	Compression      string  // snappy or zstd for large entries, empty for none
+	CompressAbove    int     // bytes an entry needs to be compressed
 }
+
 type Config struct {
 	App       AppConfig
 	Db        DbConfig
 	Redis     RedisConfig
 	Group     GroupConfig
 	Selection SelectionConfig
+	Baan      BaanConfig
 	Pin       PinConfig
+	Auth      AuthConfig
+	Cache     CacheConfig
 }
 
 func LoadConfig() (*Config, error) {
@@ -55,8 +102,9 @@ func LoadConfig() (*Config, error) {
 	}
 
 	appConfig := AppConfig{
-		Port: os.Getenv("APP_PORT"),
-		Env:  os.Getenv("APP_ENV"),
+		Port:        os.Getenv("APP_PORT"),
+		MetricsPort: os.Getenv("APP_METRICS_PORT"),
+		Env:         os.Getenv("APP_ENV"),
 	}
 
 	dbConfig := DbConfig{
@@ -82,17 +130,56 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	groupJoinRequestTTL, err := parseInt(os.Getenv("GROUP_JOIN_REQUEST_TTL"))
+	if err != nil {
+		return nil, err
+	}
+	groupMinSize, err := parseInt(os.Getenv("GROUP_MIN_SIZE"))
+	if err != nil {
+		return nil, err
+	}
+	if int(groupMinSize) > int(groupCapacity) {
+		return nil, fmt.Errorf("GROUP_MIN_SIZE %v is larger than GROUP_CAPACITY %v", groupMinSize, groupCapacity)
+	}
+	groupCohortCapacities, err := parseCohortCapacities(os.Getenv("GROUP_COHORT_CAPACITIES"))
+	if err != nil {
+		return nil, err
+	}
+	groupSelectionPolicy, err := parseSelectionPolicy(os.Getenv("GROUP_SELECTION_POLICY"))
+	if err != nil {
+		return nil, err
+	}
 	groupConfig := GroupConfig{
-		Capacity: int(groupCapacity),
-		CacheTTL: int(groupCacheTTL),
+		Capacity:         int(groupCapacity),
+		MinSize:          int(groupMinSize),
+		CohortCapacities: groupCohortCapacities,
+		CacheTTL:         int(groupCacheTTL),
+		LeaderSuccession: os.Getenv("GROUP_LEADER_SUCCESSION") == "true",
+		JoinApproval:     os.Getenv("GROUP_JOIN_APPROVAL") == "true",
+		JoinRequestTTL:   int(groupJoinRequestTTL),
+		SelectionPolicy:  groupSelectionPolicy,
 	}
 
 	selectionCacheTTL, err := strconv.ParseInt(os.Getenv("SELECTION_CACHE_TTL"), 10, 64)
 	if err != nil {
 		return nil, err
 	}
+	selectionOpenAt, err := parseTime(os.Getenv("SELECTION_OPEN_AT"))
+	if err != nil {
+		return nil, err
+	}
+	selectionCloseAt, err := parseTime(os.Getenv("SELECTION_CLOSE_AT"))
+	if err != nil {
+		return nil, err
+	}
 	selectionConfig := SelectionConfig{
 		CacheTTL: int(selectionCacheTTL),
+		OpenAt:   selectionOpenAt,
+		CloseAt:  selectionCloseAt,
+	}
+
+	baanConfig := BaanConfig{
+		SeedFile: os.Getenv("BAAN_SEED_FILE"),
 	}
 
 	workshopCount, err := strconv.ParseInt(os.Getenv("PIN_WORKSHOP_COUNT"), 10, 64)
@@ -103,11 +190,71 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	pinTimeout, err := parseInt(os.Getenv("PIN_TIMEOUT_MS"))
+	if err != nil {
+		return nil, err
+	}
+	pinPeriod, err := parseInt(os.Getenv("PIN_PERIOD"))
+	if err != nil {
+		return nil, err
+	}
+	if pinPeriod < 0 {
+		return nil, fmt.Errorf("PIN_PERIOD %v must not be negative", pinPeriod)
+	}
 	pinConfig := PinConfig{
 		WorkshopCode:  os.Getenv("PIN_WORKSHOP_CODE"),
 		WorkshopCount: int(workshopCount),
 		LandmarkCode:  os.Getenv("PIN_LANDMARK_CODE"),
 		LandmarkCount: int(landmarkCount),
+		Timeout:       int(pinTimeout),
+		Period:        int(pinPeriod),
+	}
+
+	authMaxSkew, err := parseInt(os.Getenv("AUTH_MAX_SKEW"))
+	if err != nil {
+		return nil, err
+	}
+	authConfig := AuthConfig{
+		Secret:  os.Getenv("AUTH_SECRET"),
+		MaxSkew: int(authMaxSkew),
+	}
+
+	cacheTimeout, err := parseInt(os.Getenv("CACHE_TIMEOUT_MS"))
+	if err != nil {
+		return nil, err
+	}
+	cacheLockTTL, err := parseInt(os.Getenv("CACHE_LOCK_TTL"))
+	if err != nil {
+		return nil, err
+	}
+	cacheEarlyRefreshBeta, err := parseFloat(os.Getenv("CACHE_EARLY_REFRESH_BETA"))
+	if err != nil {
+		return nil, err
+	}
+	cacheLocalSize, err := parseInt(os.Getenv("CACHE_LOCAL_SIZE"))
+	if err != nil {
+		return nil, err
+	}
+	cacheLocalTTL, err := parseInt(os.Getenv("CACHE_LOCAL_TTL"))
+	if err != nil {
+		return nil, err
+	}
+	cacheCompression := os.Getenv("CACHE_COMPRESSION")
+	if cacheCompression != "" && cacheCompression != "snappy" && cacheCompression != "zstd" {
+		return nil, fmt.Errorf("unknown cache compression %q", cacheCompression)
+	}
+	cacheCompressAbove, err := parseInt(os.Getenv("CACHE_COMPRESS_ABOVE"))
+	if err != nil {
+		return nil, err
+	}
+	cacheConfig := CacheConfig{
+		Timeout:          int(cacheTimeout),
+		LockTTL:          int(cacheLockTTL),
+		EarlyRefreshBeta: cacheEarlyRefreshBeta,
+		LocalSize:        int(cacheLocalSize),
+		LocalTTL:         int(cacheLocalTTL),
+		Compression:      cacheCompression,
+		CompressAbove:    int(cacheCompressAbove),
 	}
 
 	return &Config{
@@ -116,10 +263,87 @@ func LoadConfig() (*Config, error) {
 		Redis:     redisConfig,
 		Group:     groupConfig,
 		Selection: selectionConfig,
+		Baan:      baanConfig,
 		Pin:       pinConfig,
+		Auth:      authConfig,
+		Cache:     cacheConfig,
 	}, nil
 }
 
 func (ac *AppConfig) IsDevelopment() bool {
 	return ac.Env == "development"
 }
+
+// parseTime parses an RFC 3339 timestamp, an empty value gives the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseInt parses an optional integer, an empty value gives 0
+func parseInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// parseFloat parses an optional float, an empty value gives 0
+func parseFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseSelectionPolicy checks the policy name, an empty value gives cascade
+func parseSelectionPolicy(value string) (constant.SelectionPolicy, error) {
+	switch policy := constant.SelectionPolicy(value); policy {
+	case "":
+		return constant.SELECTION_CASCADE, nil
+	case constant.SELECTION_CASCADE, constant.SELECTION_KEEP, constant.SELECTION_RESET:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown selection policy %q", value)
+	}
+}
+
+// parseCohortCapacities parses comma separated faculty:year:capacity rules, * matches any faculty or year
+func parseCohortCapacities(value string) ([]CohortCapacity, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	rules := strings.Split(value, ",")
+	cohorts := make([]CohortCapacity, len(rules))
+	for i, rule := range rules {
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid cohort capacity %q, expected faculty:year:capacity", rule)
+		}
+
+		if parts[0] != "*" {
+			cohorts[i].Faculty = parts[0]
+		}
+		if parts[1] != "*" {
+			year, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid year in cohort capacity %q: %w", rule, err)
+			}
+			cohorts[i].Year = year
+		}
+
+		capacity, err := strconv.Atoi(parts[2])
+		if err != nil || capacity < 1 {
+			return nil, fmt.Errorf("invalid capacity in cohort capacity %q", rule)
+		}
+		cohorts[i].Capacity = capacity
+	}
+
+	return cohorts, nil
+}
@@ -0,0 +1,37 @@
+package constant
+
+type GroupEventType string
+
+const (
+	GROUP_CREATED        GroupEventType = "created"
+	GROUP_JOINED         GroupEventType = "joined"
+	GROUP_LEFT           GroupEventType = "left"
+	GROUP_REMOVED        GroupEventType = "removed"
+	GROUP_CONFIRMED      GroupEventType = "confirmed"
+	GROUP_UNCONFIRMED    GroupEventType = "unconfirmed"
+	GROUP_LEADER_CHANGED GroupEventType = "leader-changed"
+	GROUP_TOKEN_ROTATED  GroupEventType = "token-rotated"
+	GROUP_DELETED        GroupEventType = "deleted"
+	// the group's selections were cleared because its members changed, the leader has to re-rank
+	GROUP_SELECTIONS_RESET GroupEventType = "selections-reset"
+)
+
+func (t GroupEventType) String() string {
+	return string(t)
+}
+
+// SelectionPolicy decides what happens to a group's selections when its members change
+type SelectionPolicy string
+
+const (
+	// the joined group keeps its rankings, a group deleted by the move loses its own
+	SELECTION_CASCADE SelectionPolicy = "cascade"
+	// like cascade, but a joined group without rankings takes over those of the deleted group
+	SELECTION_KEEP SelectionPolicy = "keep"
+	// every group whose members change loses its rankings, so the leader re-ranks for the new members
+	SELECTION_RESET SelectionPolicy = "reset"
+)
+
+func (p SelectionPolicy) String() string {
+	return string(p)
+}
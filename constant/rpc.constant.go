@@ -0,0 +1,16 @@
+package constant
+
+// Methods of the JSON services, which serve the methods whose messages are not in rpkm67-go-proto yet
+const (
+	GroupJSONService_ListJoinRequests_FullMethodName   = "/rpkm67.backend.group.v1.GroupJSONService/ListJoinRequests"
+	GroupJSONService_AcceptJoinRequest_FullMethodName  = "/rpkm67.backend.group.v1.GroupJSONService/AcceptJoinRequest"
+	GroupJSONService_RejectJoinRequest_FullMethodName  = "/rpkm67.backend.group.v1.GroupJSONService/RejectJoinRequest"
+	GroupJSONService_TransferLeadership_FullMethodName = "/rpkm67.backend.group.v1.GroupJSONService/TransferLeadership"
+	GroupJSONService_RotateToken_FullMethodName        = "/rpkm67.backend.group.v1.GroupJSONService/RotateToken"
+	GroupJSONService_Disband_FullMethodName            = "/rpkm67.backend.group.v1.GroupJSONService/Disband"
+)
+
+const (
+	SelectionJSONService_ReplaceAll_FullMethodName            = "/rpkm67.backend.selection.v1.SelectionJSONService/ReplaceAll"
+	SelectionJSONService_CountCapacityByBaanId_FullMethodName = "/rpkm67.backend.selection.v1.SelectionJSONService/CountCapacityByBaanId"
+)
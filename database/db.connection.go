@@ -2,6 +2,8 @@ package database
 
 import (
 	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/allocation"
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -20,7 +22,7 @@ func InitDatabase(conf *config.DbConfig, isDebug bool) (db *gorm.DB, err error)
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&model.Group{}, &model.User{}, &model.Selection{}, &model.Stamp{}, &model.CheckIn{}, &model.Count{}, &model.Answer{})
+	err = db.AutoMigrate(&model.Group{}, &model.User{}, &model.Selection{}, &model.Stamp{}, &model.CheckIn{}, &model.Count{}, &model.Answer{}, &allocation.Allocation{}, &group.TokenPolicy{}, &group.Membership{}, &group.JoinRequest{}, &group.GroupEvent{}, &group.WindowState{}, &group.ConfirmSnapshot{}, &group.SnapshotMember{}, &group.SnapshotSelection{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,630 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/allocation"
+	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"github.com/isd-sgcu/rpkm67-backend/internal/user"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	"github.com/isd-sgcu/rpkm67-model/model"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEventPageSize = 20
+	maxEventPageSize     = 100
+)
+
+// Service lets staff repair groups. Unlike the group service it ignores the selection window
+// and confirmation, but still keeps every group within capacity and led by one of its members.
+// A confirmed group whose members change is snapshotted again for allocation.
+type Service interface {
+	MoveUser(ctx context.Context, in *dto.MoveUserAdminRequest) (*dto.MoveUserAdminResponse, error)
+	MergeGroups(ctx context.Context, in *dto.MergeGroupsAdminRequest) (*dto.MergeGroupsAdminResponse, error)
+	SplitGroup(ctx context.Context, in *dto.SplitGroupAdminRequest) (*dto.SplitGroupAdminResponse, error)
+	UpdateConfirm(ctx context.Context, in *dto.UpdateConfirmAdminRequest) (*dto.UpdateConfirmAdminResponse, error)
+	DeleteOrphanGroups(ctx context.Context, in *dto.DeleteOrphanGroupsAdminRequest) (*dto.DeleteOrphanGroupsAdminResponse, error)
+	FindEvents(ctx context.Context, in *dto.FindEventsAdminRequest) (*dto.FindEventsAdminResponse, error)
+	SetWindowOverride(ctx context.Context, in *dto.SetWindowOverrideAdminRequest) (*dto.SetWindowOverrideAdminResponse, error)
+	Allocate(ctx context.Context, in *dto.AllocateAdminRequest) (*dto.AllocateResponse, error)
+}
+
+type serviceImpl struct {
+	groupRepo     group.Repository
+	userRepo      user.Repository
+	allocationSvc allocation.Service
+	cache         cache.Aside
+	conf          *config.GroupConfig
+	log           *zap.Logger
+}
+
+func NewService(groupRepo group.Repository, userRepo user.Repository, allocationSvc allocation.Service, cache cache.Aside, conf *config.GroupConfig, log *zap.Logger) Service {
+	return &serviceImpl{
+		groupRepo:     groupRepo,
+		userRepo:      userRepo,
+		allocationSvc: allocationSvc,
+		cache:         cache,
+		conf:          conf,
+		log:           log,
+	}
+}
+
+// MoveUser moves a user into another group. A user leading a group with other members has to
+// hand over leadership first; a solo group left behind is deleted.
+func (s *serviceImpl) MoveUser(ctx context.Context, in *dto.MoveUserAdminRequest) (*dto.MoveUserAdminResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("MoveUser").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	var touched []*model.Group
+	err = s.groupRepo.WithTransaction(func(tx *gorm.DB) error {
+		touched = nil
+
+		movedUser := &model.User{}
+		if err := s.userRepo.FindOneForUpdateTX(tx, in.UserId, movedUser); err != nil {
+			s.log.Named("MoveUser").Error("FindOneForUpdateTX user: ", zap.Error(err))
+			return status.Error(codes.NotFound, "user not found")
+		}
+
+		target := &model.Group{}
+		if err := s.groupRepo.FindOneForUpdateTX(tx, in.GroupId, target); err != nil {
+			s.log.Named("MoveUser").Error("FindOneForUpdateTX target: ", zap.Error(err))
+			return status.Error(codes.NotFound, "group not found")
+		}
+		touched = append(touched, target)
+
+		if movedUser.GroupID != nil && *movedUser.GroupID == target.ID {
+			s.log.Named("MoveUser").Error("User is already in the group", zap.String("user_id", in.UserId))
+			return status.Error(codes.InvalidArgument, "user is already in the group")
+		}
+
+		if len(target.Members) >= group.Capacity(s.conf, append(append([]*model.User{}, target.Members...), movedUser)) {
+			s.log.Named("MoveUser").Error("Group is full", zap.String("group_id", in.GroupId))
+			return status.Error(codes.FailedPrecondition, "group is full")
+		}
+
+		var source *model.Group
+		if movedUser.GroupID != nil {
+			source = &model.Group{}
+			if err := s.groupRepo.FindOneForUpdateTX(tx, movedUser.GroupID.String(), source); err != nil {
+				s.log.Named("MoveUser").Error("FindOneForUpdateTX source: ", zap.Error(err))
+				return status.Error(codes.Internal, "failed to find the user's group")
+			}
+			touched = append(touched, source)
+
+			if *source.LeaderID == movedUser.ID && len(source.Members) > 1 {
+				s.log.Named("MoveUser").Error("User leads a group with other members", zap.String("user_id", in.UserId))
+				return status.Error(codes.FailedPrecondition, "user leads a group with other members, transfer the leadership first")
+			}
+		}
+
+		if err := s.moveUserTX(tx, movedUser.ID, source, target, staffId); err != nil {
+			return err
+		}
+
+		if source != nil && len(source.Members) == 1 {
+			if err := s.deleteGroupTX(tx, source, staffId); err != nil {
+				return err
+			}
+			return s.resnapshotTX(tx, target)
+		}
+
+		return s.resnapshotTX(tx, touched...)
+	})
+
+	if err != nil {
+		s.log.Named("MoveUser").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if err := s.invalidateCache(ctx, touched...); err != nil {
+		s.log.Named("MoveUser").Error("invalidateCache: ", zap.Error(err))
+	}
+
+	target, err := s.findGroup(in.GroupId)
+	if err != nil {
+		s.log.Named("MoveUser").Error("findGroup: ", zap.Error(err))
+		return nil, err
+	}
+
+	return &dto.MoveUserAdminResponse{Group: group.ModelToProto(target)}, nil
+}
+
+// MergeGroups moves every member of the source group into the target group and deletes the source
+func (s *serviceImpl) MergeGroups(ctx context.Context, in *dto.MergeGroupsAdminRequest) (*dto.MergeGroupsAdminResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("MergeGroups").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	if in.SourceGroupId == in.TargetGroupId {
+		s.log.Named("MergeGroups").Error("Source and target are the same group", zap.String("group_id", in.SourceGroupId))
+		return nil, status.Error(codes.InvalidArgument, "cannot merge a group into itself")
+	}
+
+	var touched []*model.Group
+	err = s.groupRepo.WithTransaction(func(tx *gorm.DB) error {
+		groups, err := s.lockGroupsTX(tx, in.SourceGroupId, in.TargetGroupId)
+		if err != nil {
+			return err
+		}
+		source, target := groups[0], groups[1]
+		touched = groups
+
+		merged := append(append([]*model.User{}, target.Members...), source.Members...)
+		if capacity := group.Capacity(s.conf, merged); len(merged) > capacity {
+			s.log.Named("MergeGroups").Error("Merged group exceeds capacity", zap.Int("members", len(merged)))
+			return status.Error(codes.FailedPrecondition, fmt.Sprintf("merged group would have more than %v members", capacity))
+		}
+
+		for _, member := range source.Members {
+			if err := s.moveUserTX(tx, member.ID, source, target, staffId); err != nil {
+				return err
+			}
+		}
+
+		if err := s.deleteGroupTX(tx, source, staffId); err != nil {
+			return err
+		}
+
+		return s.resnapshotTX(tx, target)
+	})
+
+	if err != nil {
+		s.log.Named("MergeGroups").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if err := s.invalidateCache(ctx, touched...); err != nil {
+		s.log.Named("MergeGroups").Error("invalidateCache: ", zap.Error(err))
+	}
+
+	target, err := s.findGroup(in.TargetGroupId)
+	if err != nil {
+		s.log.Named("MergeGroups").Error("findGroup: ", zap.Error(err))
+		return nil, err
+	}
+
+	return &dto.MergeGroupsAdminResponse{Group: group.ModelToProto(target)}, nil
+}
+
+// SplitGroup moves some members out of a group into a new group led by the first of them.
+// The leader of the group stays where they are.
+func (s *serviceImpl) SplitGroup(ctx context.Context, in *dto.SplitGroupAdminRequest) (*dto.SplitGroupAdminResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("SplitGroup").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	if len(in.UserIds) == 0 {
+		s.log.Named("SplitGroup").Error("No users to split", zap.String("group_id", in.GroupId))
+		return nil, status.Error(codes.InvalidArgument, "user_ids must not be empty")
+	}
+
+	var touched []*model.Group
+	newGroup := &model.Group{}
+	err = s.groupRepo.WithTransaction(func(tx *gorm.DB) error {
+		source := &model.Group{}
+		if err := s.groupRepo.FindOneForUpdateTX(tx, in.GroupId, source); err != nil {
+			s.log.Named("SplitGroup").Error("FindOneForUpdateTX: ", zap.Error(err))
+			return status.Error(codes.NotFound, "group not found")
+		}
+		touched = []*model.Group{source}
+
+		members := make(map[string]*model.User, len(source.Members))
+		for _, member := range source.Members {
+			members[member.ID.String()] = member
+		}
+
+		movedUsers := make([]*model.User, len(in.UserIds))
+		for i, userId := range in.UserIds {
+			member, ok := members[userId]
+			if !ok {
+				s.log.Named("SplitGroup").Error("User is not in the group", zap.String("user_id", userId))
+				return status.Error(codes.InvalidArgument, fmt.Sprintf("user %s is not in the group or listed twice", userId))
+			}
+			if member.ID == *source.LeaderID {
+				s.log.Named("SplitGroup").Error("Leader cannot be split off", zap.String("user_id", userId))
+				return status.Error(codes.InvalidArgument, "the group leader stays in the group")
+			}
+			delete(members, userId)
+			movedUsers[i] = member
+		}
+
+		newGroup.LeaderID = &movedUsers[0].ID
+		if err := s.groupRepo.CreateTX(tx, newGroup); err != nil {
+			s.log.Named("SplitGroup").Error("CreateTX: ", zap.Error(err))
+			return fmt.Errorf("failed to create new group: %w", err)
+		}
+
+		err := s.recordEventsTX(tx, &group.GroupEvent{GroupID: newGroup.ID, Type: constant.GROUP_CREATED, UserID: newGroup.LeaderID, ActorID: &staffId})
+		if err != nil {
+			return err
+		}
+
+		for _, movedUser := range movedUsers {
+			if err := s.moveUserTX(tx, movedUser.ID, source, newGroup, staffId); err != nil {
+				return err
+			}
+		}
+
+		return s.resnapshotTX(tx, source)
+	})
+
+	if err != nil {
+		s.log.Named("SplitGroup").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if err := s.invalidateCache(ctx, touched...); err != nil {
+		s.log.Named("SplitGroup").Error("invalidateCache: ", zap.Error(err))
+	}
+
+	source, err := s.findGroup(in.GroupId)
+	if err != nil {
+		s.log.Named("SplitGroup").Error("findGroup source: ", zap.Error(err))
+		return nil, err
+	}
+	newGroup, err = s.findGroup(newGroup.ID.String())
+	if err != nil {
+		s.log.Named("SplitGroup").Error("findGroup newGroup: ", zap.Error(err))
+		return nil, err
+	}
+
+	return &dto.SplitGroupAdminResponse{
+		Group:    group.ModelToProto(source),
+		NewGroup: group.ModelToProto(newGroup),
+	}, nil
+}
+
+// UpdateConfirm confirms or unconfirms a group regardless of the selection window
+func (s *serviceImpl) UpdateConfirm(ctx context.Context, in *dto.UpdateConfirmAdminRequest) (*dto.UpdateConfirmAdminResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("UpdateConfirm").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	updatedGroup := &model.Group{}
+	err = s.groupRepo.WithTransaction(func(tx *gorm.DB) error {
+		if err := s.groupRepo.FindOneForUpdateTX(tx, in.GroupId, updatedGroup); err != nil {
+			s.log.Named("UpdateConfirm").Error("FindOneForUpdateTX: ", zap.Error(err))
+			return status.Error(codes.NotFound, "group not found")
+		}
+
+		updatedGroup.IsConfirmed = in.IsConfirmed
+		if err := s.groupRepo.UpdateConfirmTX(tx, in.GroupId, updatedGroup); err != nil {
+			s.log.Named("UpdateConfirm").Error("UpdateConfirmTX: ", zap.Error(err))
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+
+		if in.IsConfirmed {
+			if err := s.groupRepo.CreateSnapshotTX(tx, in.GroupId); err != nil {
+				s.log.Named("UpdateConfirm").Error("CreateSnapshotTX: ", zap.Error(err))
+				return fmt.Errorf("failed to create snapshot: %w", err)
+			}
+		} else if err := s.groupRepo.SupersedeSnapshotTX(tx, in.GroupId); err != nil {
+			s.log.Named("UpdateConfirm").Error("SupersedeSnapshotTX: ", zap.Error(err))
+			return fmt.Errorf("failed to supersede snapshot: %w", err)
+		}
+
+		eventType := constant.GROUP_CONFIRMED
+		if !in.IsConfirmed {
+			eventType = constant.GROUP_UNCONFIRMED
+		}
+
+		return s.recordEventsTX(tx, &group.GroupEvent{GroupID: updatedGroup.ID, Type: eventType, ActorID: &staffId})
+	})
+
+	if err != nil {
+		s.log.Named("UpdateConfirm").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if err := s.invalidateCache(ctx, updatedGroup); err != nil {
+		s.log.Named("UpdateConfirm").Error("invalidateCache: ", zap.Error(err))
+	}
+
+	return &dto.UpdateConfirmAdminResponse{Group: group.ModelToProto(updatedGroup)}, nil
+}
+
+// DeleteOrphanGroups deletes every group that no user belongs to
+func (s *serviceImpl) DeleteOrphanGroups(ctx context.Context, in *dto.DeleteOrphanGroupsAdminRequest) (*dto.DeleteOrphanGroupsAdminResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("DeleteOrphanGroups").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	orphans := []model.Group{}
+	err = s.groupRepo.WithTransaction(func(tx *gorm.DB) error {
+		if err := s.groupRepo.FindOrphansForUpdateTX(tx, &orphans); err != nil {
+			s.log.Named("DeleteOrphanGroups").Error("FindOrphansForUpdateTX: ", zap.Error(err))
+			return fmt.Errorf("failed to find orphan groups: %w", err)
+		}
+
+		for i := range orphans {
+			if err := s.deleteGroupTX(tx, &orphans[i], staffId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		s.log.Named("DeleteOrphanGroups").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	groupIds := make([]string, len(orphans))
+	for i := range orphans {
+		if err := s.invalidateCache(ctx, &orphans[i]); err != nil {
+			s.log.Named("DeleteOrphanGroups").Error("invalidateCache: ", zap.Error(err))
+		}
+		groupIds[i] = orphans[i].ID.String()
+	}
+
+	s.log.Named("DeleteOrphanGroups").Info("Orphan groups deleted", zap.Int("count", len(orphans)))
+
+	return &dto.DeleteOrphanGroupsAdminResponse{GroupIds: groupIds}, nil
+}
+
+// FindEvents pages through the history of a group or a user, newest first
+func (s *serviceImpl) FindEvents(_ context.Context, in *dto.FindEventsAdminRequest) (*dto.FindEventsAdminResponse, error) {
+	if _, err := s.checkStaff(in.StaffId); err != nil {
+		s.log.Named("FindEvents").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	if (in.GroupId == "") == (in.UserId == "") {
+		s.log.Named("FindEvents").Error("Invalid filter", zap.String("group_id", in.GroupId), zap.String("user_id", in.UserId))
+		return nil, status.Error(codes.InvalidArgument, "exactly one of group_id and user_id must be set")
+	}
+
+	page := max(in.Page, 1)
+	pageSize := in.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultEventPageSize
+	}
+	pageSize = min(pageSize, maxEventPageSize)
+	offset := (page - 1) * pageSize
+
+	events := []group.GroupEvent{}
+	var total int64
+	var err error
+	if in.GroupId != "" {
+		err = s.groupRepo.FindEventsByGroupId(in.GroupId, offset, pageSize, &events, &total)
+	} else {
+		err = s.groupRepo.FindEventsByUserId(in.UserId, offset, pageSize, &events, &total)
+	}
+	if err != nil {
+		s.log.Named("FindEvents").Error("FindEvents: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find group events")
+	}
+
+	eventsDTO := make([]*dto.GroupEvent, len(events))
+	for i := range events {
+		eventsDTO[i] = group.EventToDTO(&events[i])
+	}
+
+	return &dto.FindEventsAdminResponse{
+		Events:   eventsDTO,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// SetWindowOverride keeps the selection window open past its close time, or closes it again. Every
+// replica picks it up from the window state; groups are not locked while it is overridden.
+func (s *serviceImpl) SetWindowOverride(_ context.Context, in *dto.SetWindowOverrideAdminRequest) (*dto.SetWindowOverrideAdminResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("SetWindowOverride").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	state := &group.WindowState{}
+	err = s.groupRepo.WithTransaction(func(tx *gorm.DB) error {
+		if err := s.groupRepo.FindWindowStateForUpdateTX(tx, state); err != nil {
+			s.log.Named("SetWindowOverride").Error("FindWindowStateForUpdateTX: ", zap.Error(err))
+			return fmt.Errorf("failed to find window state: %w", err)
+		}
+
+		now := time.Now()
+		state.Override = in.Override
+		state.OverriddenBy = &staffId
+		state.OverriddenAt = &now
+		if err := s.groupRepo.SaveWindowStateTX(tx, state); err != nil {
+			s.log.Named("SetWindowOverride").Error("SaveWindowStateTX: ", zap.Error(err))
+			return fmt.Errorf("failed to save window state: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		s.log.Named("SetWindowOverride").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	s.log.Named("SetWindowOverride").Info("Window override changed", zap.Bool("override", in.Override), zap.String("staff_id", staffId.String()))
+
+	return &dto.SetWindowOverrideAdminResponse{
+		Override:     state.Override,
+		OverriddenBy: staffId.String(),
+		OverriddenAt: *state.OverriddenAt,
+		LockedAt:     state.LockedAt,
+	}, nil
+}
+
+// Allocate assigns the confirmed groups to baans on behalf of staff. A dry run reports the result
+// without saving it, and its seed reproduces the same allocation when run again to persist it.
+func (s *serviceImpl) Allocate(ctx context.Context, in *dto.AllocateAdminRequest) (*dto.AllocateResponse, error) {
+	staffId, err := s.checkStaff(in.StaffId)
+	if err != nil {
+		s.log.Named("Allocate").Error("checkStaff: ", zap.Error(err))
+		return nil, err
+	}
+
+	if !in.DryRun && in.Seed == 0 {
+		s.log.Named("Allocate").Error("Persisting without a seed", zap.String("staff_id", staffId.String()))
+		return nil, status.Error(codes.InvalidArgument, "persisting an allocation needs the seed of the dry run it reproduces")
+	}
+
+	res, err := s.allocationSvc.Allocate(ctx, &dto.AllocateRequest{Seed: in.Seed, DryRun: in.DryRun})
+	if err != nil {
+		s.log.Named("Allocate").Error("Allocate: ", zap.Error(err))
+		return nil, err
+	}
+
+	s.log.Named("Allocate").Info("Baans allocated", zap.Int64("seed", res.Seed), zap.Bool("dry_run", in.DryRun), zap.String("staff_id", staffId.String()))
+
+	return res, nil
+}
+
+// checkStaff checks that the staff id names a user with the staff role. The id comes from the caller, who is not
+// authenticated, so this guards against mistakes rather than impersonation; see cmd/admin.
+func (s *serviceImpl) checkStaff(staffId string) (uuid.UUID, error) {
+	staff := &model.User{}
+	if err := s.userRepo.FindOne(staffId, staff); err != nil {
+		return uuid.Nil, status.Error(codes.PermissionDenied, "staff not found")
+	}
+
+	if string(staff.Role) != constant.STAFF.String() {
+		return uuid.Nil, status.Error(codes.PermissionDenied, "only staff can manage groups")
+	}
+
+	return staff.ID, nil
+}
+
+// lockGroupsTX locks the groups in id order, so two staff working on the same groups cannot deadlock.
+// The groups are returned in the order of the given ids.
+func (s *serviceImpl) lockGroupsTX(tx *gorm.DB, ids ...string) ([]*model.Group, error) {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+
+	locked := make(map[string]*model.Group, len(ids))
+	for _, id := range sorted {
+		g := &model.Group{}
+		if err := s.groupRepo.FindOneForUpdateTX(tx, id, g); err != nil {
+			s.log.Named("lockGroupsTX").Error("FindOneForUpdateTX: ", zap.Error(err))
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("group %s not found", id))
+		}
+		locked[id] = g
+	}
+
+	groups := make([]*model.Group, len(ids))
+	for i, id := range ids {
+		groups[i] = locked[id]
+	}
+
+	return groups, nil
+}
+
+// moveUserTX assigns the user to the target group and withdraws their join requests.
+// source is nil if the user had no group yet.
+func (s *serviceImpl) moveUserTX(tx *gorm.DB, userId uuid.UUID, source *model.Group, target *model.Group, staffId uuid.UUID) error {
+	if err := s.userRepo.AssignGroupTX(tx, userId.String(), &target.ID); err != nil {
+		s.log.Named("moveUserTX").Error("AssignGroupTX: ", zap.Error(err))
+		return fmt.Errorf("failed to assign user to group: %w", err)
+	}
+
+	membership := &group.Membership{
+		UserID:   userId,
+		GroupID:  target.ID,
+		JoinedAt: time.Now(),
+	}
+	if err := s.groupRepo.SaveMembershipTX(tx, membership); err != nil {
+		s.log.Named("moveUserTX").Error("SaveMembershipTX: ", zap.Error(err))
+		return fmt.Errorf("failed to save membership: %w", err)
+	}
+
+	if err := s.groupRepo.DeleteJoinRequestsByUserIdTX(tx, userId.String()); err != nil {
+		s.log.Named("moveUserTX").Error("DeleteJoinRequestsByUserIdTX: ", zap.Error(err))
+		return fmt.Errorf("failed to delete join requests: %w", err)
+	}
+
+	events := []*group.GroupEvent{
+		{GroupID: target.ID, Type: constant.GROUP_JOINED, UserID: &userId, ActorID: &staffId},
+	}
+	if source != nil {
+		events[0].RelatedGroupID = &source.ID
+		events = append(events, &group.GroupEvent{GroupID: source.ID, Type: constant.GROUP_LEFT, UserID: &userId, ActorID: &staffId, RelatedGroupID: &target.ID})
+	}
+
+	return s.recordEventsTX(tx, events...)
+}
+
+// resnapshotTX snapshots the confirmed groups again after their members changed, so allocation sees
+// them as they are now. Deleted groups have their snapshot superseded by DeleteGroupTX.
+func (s *serviceImpl) resnapshotTX(tx *gorm.DB, groups ...*model.Group) error {
+	for _, g := range groups {
+		if !g.IsConfirmed {
+			continue
+		}
+
+		if err := s.groupRepo.CreateSnapshotTX(tx, g.ID.String()); err != nil {
+			s.log.Named("resnapshotTX").Error("CreateSnapshotTX: ", zap.Error(err))
+			return fmt.Errorf("failed to create snapshot: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *serviceImpl) deleteGroupTX(tx *gorm.DB, deleted *model.Group, staffId uuid.UUID) error {
+	if err := s.groupRepo.DeleteGroupTX(tx, &deleted.ID); err != nil {
+		s.log.Named("deleteGroupTX").Error("DeleteGroupTX: ", zap.Error(err))
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return s.recordEventsTX(tx, &group.GroupEvent{GroupID: deleted.ID, Type: constant.GROUP_DELETED, ActorID: &staffId})
+}
+
+func (s *serviceImpl) recordEventsTX(tx *gorm.DB, events ...*group.GroupEvent) error {
+	if err := s.groupRepo.CreateEventsTX(tx, events); err != nil {
+		s.log.Named("recordEventsTX").Error("CreateEventsTX: ", zap.Error(err))
+		return fmt.Errorf("failed to record group events: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceImpl) findGroup(id string) (*model.Group, error) {
+	found := &model.Group{}
+	if err := s.groupRepo.FindOne(id, found); err != nil {
+		return nil, status.Error(codes.Internal, "failed to find group")
+	}
+
+	return found, nil
+}
+
+// invalidateCache drops the cached views of the groups as they were before the change. Every user
+// whose group changed was a member of one of them, so the next read of each goes to the database.
+// A failure is logged without failing the command, which has committed already.
+func (s *serviceImpl) invalidateCache(ctx context.Context, groups ...*model.Group) error {
+	var keys []string
+	for _, g := range groups {
+		keys = append(keys, group.CacheKeys(g)...)
+	}
+
+	return s.cache.Invalidate(ctx, keys...)
+}
@@ -0,0 +1,386 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/admin"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	mock_allocation "github.com/isd-sgcu/rpkm67-backend/mocks/allocation"
+	mock_cache "github.com/isd-sgcu/rpkm67-backend/mocks/cache"
+	mock_group "github.com/isd-sgcu/rpkm67-backend/mocks/group"
+	mock_user "github.com/isd-sgcu/rpkm67-backend/mocks/user"
+	"github.com/isd-sgcu/rpkm67-model/model"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type AdminServiceTestSuite struct {
+	suite.Suite
+	ctrl              *gomock.Controller
+	mockGroupRepo     *mock_group.MockRepository
+	mockUserRepo      *mock_user.MockRepository
+	mockAllocationSvc *mock_allocation.MockService
+	mockCache         *mock_cache.MockAside
+	service           admin.Service
+	ctx               context.Context
+	staff             *model.User
+	events            []*group.GroupEvent // recorded by CreateEventsTX
+}
+
+func TestAdminServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminServiceTestSuite))
+}
+
+func (s *AdminServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockGroupRepo = mock_group.NewMockRepository(s.ctrl)
+	s.mockUserRepo = mock_user.NewMockRepository(s.ctrl)
+	s.mockAllocationSvc = mock_allocation.NewMockService(s.ctrl)
+	s.mockCache = mock_cache.NewMockAside(s.ctrl)
+	s.mockGroupRepo.EXPECT().WithTransaction(gomock.Any()).DoAndReturn(func(txFunc func(*gorm.DB) error) error {
+		return txFunc(nil)
+	}).AnyTimes()
+	s.events = nil
+	s.mockGroupRepo.EXPECT().CreateEventsTX(gomock.Any(), gomock.Any()).DoAndReturn(func(_ *gorm.DB, events []*group.GroupEvent) error {
+		s.events = append(s.events, events...)
+		return nil
+	}).AnyTimes()
+	s.service = admin.NewService(s.mockGroupRepo, s.mockUserRepo, s.mockAllocationSvc, s.mockCache, &config.GroupConfig{Capacity: 3}, zap.NewNop())
+	s.ctx = context.Background()
+
+	s.staff = &model.User{Base: model.Base{ID: uuid.New()}, Role: "staff"}
+}
+
+func (s *AdminServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *AdminServiceTestSuite) TestMoveUser_NotStaff() {
+	s.mockUserRepo.EXPECT().FindOne(s.staff.ID.String(), gomock.Any()).SetArg(1, model.User{Base: s.staff.Base, Role: "user"}).Return(nil)
+
+	res, err := s.service.MoveUser(s.ctx, &dto.MoveUserAdminRequest{StaffId: s.staff.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestMoveUser_DeletesSoloGroup() {
+	movedUser, source := newGroup(1)
+	_, target := newGroup(2)
+	movedUser.GroupID = &source.ID
+
+	s.expectStaff()
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), movedUser.ID.String(), gomock.Any()).SetArg(2, *movedUser).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), target.ID.String(), gomock.Any()).SetArg(2, *target).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), movedUser.ID.String(), &target.ID).Return(nil)
+	s.mockGroupRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), movedUser.ID.String()).Return(nil)
+	s.mockGroupRepo.EXPECT().DeleteGroupTX(gomock.Any(), &source.ID).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOne(target.ID.String(), gomock.Any()).SetArg(1, *target).Return(nil)
+
+	res, err := s.service.MoveUser(s.ctx, &dto.MoveUserAdminRequest{
+		StaffId: s.staff.ID.String(),
+		UserId:  movedUser.ID.String(),
+		GroupId: target.ID.String(),
+	})
+
+	s.NoError(err)
+	s.Equal(target.ID.String(), res.Group.Id)
+	s.Equal([]constant.GroupEventType{constant.GROUP_JOINED, constant.GROUP_LEFT, constant.GROUP_DELETED}, eventTypes(s.events))
+	for _, event := range s.events {
+		s.Equal(&s.staff.ID, event.ActorID)
+	}
+}
+
+func (s *AdminServiceTestSuite) TestMoveUser_ResnapshotsConfirmedGroups() {
+	_, source := newGroup(2)
+	_, target := newGroup(1)
+	movedUser := source.Members[1]
+	source.IsConfirmed, target.IsConfirmed = true, true
+
+	s.expectStaff()
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), movedUser.ID.String(), gomock.Any()).SetArg(2, *movedUser).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), target.ID.String(), gomock.Any()).SetArg(2, *target).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), movedUser.ID.String(), &target.ID).Return(nil)
+	s.mockGroupRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), movedUser.ID.String()).Return(nil)
+	// both snapshots are taken after the move, in the same transaction
+	s.mockGroupRepo.EXPECT().CreateSnapshotTX(gomock.Any(), target.ID.String()).Return(nil)
+	s.mockGroupRepo.EXPECT().CreateSnapshotTX(gomock.Any(), source.ID.String()).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOne(target.ID.String(), gomock.Any()).SetArg(1, *target).Return(nil)
+
+	_, err := s.service.MoveUser(s.ctx, &dto.MoveUserAdminRequest{
+		StaffId: s.staff.ID.String(),
+		UserId:  movedUser.ID.String(),
+		GroupId: target.ID.String(),
+	})
+
+	s.NoError(err)
+}
+
+func (s *AdminServiceTestSuite) TestMoveUser_SnapshotFailed() {
+	movedUser, source := newGroup(1)
+	_, target := newGroup(1)
+	movedUser.GroupID = &source.ID
+	target.IsConfirmed = true
+
+	s.expectStaff()
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), movedUser.ID.String(), gomock.Any()).SetArg(2, *movedUser).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), target.ID.String(), gomock.Any()).SetArg(2, *target).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), movedUser.ID.String(), &target.ID).Return(nil)
+	s.mockGroupRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), movedUser.ID.String()).Return(nil)
+	s.mockGroupRepo.EXPECT().DeleteGroupTX(gomock.Any(), &source.ID).Return(nil)
+	s.mockGroupRepo.EXPECT().CreateSnapshotTX(gomock.Any(), target.ID.String()).Return(gorm.ErrInvalidDB)
+
+	res, err := s.service.MoveUser(s.ctx, &dto.MoveUserAdminRequest{
+		StaffId: s.staff.ID.String(),
+		UserId:  movedUser.ID.String(),
+		GroupId: target.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.Internal, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestMoveUser_LeaderWithMembers() {
+	_, source := newGroup(2)
+	_, target := newGroup(1)
+	leader := source.Members[0]
+
+	s.expectStaff()
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), leader.ID.String(), gomock.Any()).SetArg(2, *leader).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), target.ID.String(), gomock.Any()).SetArg(2, *target).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+
+	res, err := s.service.MoveUser(s.ctx, &dto.MoveUserAdminRequest{
+		StaffId: s.staff.ID.String(),
+		UserId:  leader.ID.String(),
+		GroupId: target.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestMergeGroups_OverCapacity() {
+	_, source := newGroup(2)
+	_, target := newGroup(2)
+
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), target.ID.String(), gomock.Any()).SetArg(2, *target).Return(nil)
+
+	res, err := s.service.MergeGroups(s.ctx, &dto.MergeGroupsAdminRequest{
+		StaffId:       s.staff.ID.String(),
+		SourceGroupId: source.ID.String(),
+		TargetGroupId: target.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestMergeGroups_Success() {
+	_, source := newGroup(2)
+	_, target := newGroup(1)
+	target.IsConfirmed = true
+
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), target.ID.String(), gomock.Any()).SetArg(2, *target).Return(nil)
+	for _, member := range source.Members {
+		s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), member.ID.String(), &target.ID).Return(nil)
+		s.mockGroupRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), member.ID.String()).Return(nil)
+	}
+	s.mockGroupRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	s.mockGroupRepo.EXPECT().DeleteGroupTX(gomock.Any(), &source.ID).Return(nil)
+	s.mockGroupRepo.EXPECT().CreateSnapshotTX(gomock.Any(), target.ID.String()).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	merged := *target
+	merged.Members = append(append([]*model.User{}, target.Members...), source.Members...)
+	s.mockGroupRepo.EXPECT().FindOne(target.ID.String(), gomock.Any()).SetArg(1, merged).Return(nil)
+
+	res, err := s.service.MergeGroups(s.ctx, &dto.MergeGroupsAdminRequest{
+		StaffId:       s.staff.ID.String(),
+		SourceGroupId: source.ID.String(),
+		TargetGroupId: target.ID.String(),
+	})
+
+	s.NoError(err)
+	s.Len(res.Group.Members, 3)
+	s.Equal(target.LeaderID.String(), res.Group.LeaderID)
+}
+
+func (s *AdminServiceTestSuite) TestSplitGroup_LeaderListed() {
+	_, source := newGroup(3)
+
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), source.ID.String(), gomock.Any()).SetArg(2, *source).Return(nil)
+
+	res, err := s.service.SplitGroup(s.ctx, &dto.SplitGroupAdminRequest{
+		StaffId: s.staff.ID.String(),
+		GroupId: source.ID.String(),
+		UserIds: []string{source.Members[0].ID.String()},
+	})
+
+	s.Nil(res)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestDeleteOrphanGroups_Success() {
+	orphan := model.Group{Base: model.Base{ID: uuid.New()}, Token: "orphan"}
+
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindOrphansForUpdateTX(gomock.Any(), gomock.Any()).SetArg(1, []model.Group{orphan}).Return(nil)
+	s.mockGroupRepo.EXPECT().DeleteGroupTX(gomock.Any(), &orphan.ID).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), "groupByToken:orphan").Return(nil)
+
+	res, err := s.service.DeleteOrphanGroups(s.ctx, &dto.DeleteOrphanGroupsAdminRequest{StaffId: s.staff.ID.String()})
+
+	s.NoError(err)
+	s.Equal([]string{orphan.ID.String()}, res.GroupIds)
+	s.Equal([]constant.GroupEventType{constant.GROUP_DELETED}, eventTypes(s.events))
+}
+
+func (s *AdminServiceTestSuite) TestFindEvents_ByGroup() {
+	_, g := newGroup(1)
+	userId := uuid.New()
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindEventsByGroupId(g.ID.String(), 20, 20, gomock.Any(), gomock.Any()).
+		SetArg(3, []group.GroupEvent{{ID: 7, GroupID: g.ID, Type: constant.GROUP_JOINED, UserID: &userId}}).
+		SetArg(4, int64(21)).
+		Return(nil)
+
+	res, err := s.service.FindEvents(s.ctx, &dto.FindEventsAdminRequest{StaffId: s.staff.ID.String(), GroupId: g.ID.String(), Page: 2})
+
+	s.NoError(err)
+	s.Equal(int64(21), res.Total)
+	s.Equal(2, res.Page)
+	s.Equal(20, res.PageSize)
+	s.Require().Len(res.Events, 1)
+	s.Equal("joined", res.Events[0].Type)
+	s.Equal(userId.String(), res.Events[0].UserId)
+	s.Empty(res.Events[0].ActorId)
+}
+
+func (s *AdminServiceTestSuite) TestFindEvents_ByUserCapsPageSize() {
+	userId := uuid.NewString()
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindEventsByUserId(userId, 0, 100, gomock.Any(), gomock.Any()).Return(nil)
+
+	res, err := s.service.FindEvents(s.ctx, &dto.FindEventsAdminRequest{StaffId: s.staff.ID.String(), UserId: userId, PageSize: 1000})
+
+	s.NoError(err)
+	s.Equal(100, res.PageSize)
+}
+
+func (s *AdminServiceTestSuite) TestFindEvents_InvalidFilter() {
+	s.expectStaff()
+
+	res, err := s.service.FindEvents(s.ctx, &dto.FindEventsAdminRequest{StaffId: s.staff.ID.String(), GroupId: uuid.NewString(), UserId: uuid.NewString()})
+
+	s.Nil(res)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestFindEvents_NotStaff() {
+	s.mockUserRepo.EXPECT().FindOne(s.staff.ID.String(), gomock.Any()).SetArg(1, model.User{Base: s.staff.Base, Role: "user"}).Return(nil)
+
+	res, err := s.service.FindEvents(s.ctx, &dto.FindEventsAdminRequest{StaffId: s.staff.ID.String(), GroupId: uuid.NewString()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestSetWindowOverride_RecordsStaff() {
+	s.expectStaff()
+	s.mockGroupRepo.EXPECT().FindWindowStateForUpdateTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().SaveWindowStateTX(gomock.Any(), gomock.Any()).DoAndReturn(func(_ *gorm.DB, state *group.WindowState) error {
+		s.True(state.Override)
+		s.Equal(s.staff.ID, *state.OverriddenBy)
+		s.NotNil(state.OverriddenAt)
+		return nil
+	})
+
+	res, err := s.service.SetWindowOverride(s.ctx, &dto.SetWindowOverrideAdminRequest{StaffId: s.staff.ID.String(), Override: true})
+
+	s.NoError(err)
+	s.True(res.Override)
+	s.Equal(s.staff.ID.String(), res.OverriddenBy)
+}
+
+func (s *AdminServiceTestSuite) TestSetWindowOverride_NotStaff() {
+	s.mockUserRepo.EXPECT().FindOne(s.staff.ID.String(), gomock.Any()).SetArg(1, model.User{Base: s.staff.Base, Role: "user"}).Return(nil)
+
+	res, err := s.service.SetWindowOverride(s.ctx, &dto.SetWindowOverrideAdminRequest{StaffId: s.staff.ID.String(), Override: true})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestAllocate_DryRun() {
+	s.expectStaff()
+	s.mockAllocationSvc.EXPECT().Allocate(gomock.Any(), &dto.AllocateRequest{DryRun: true}).Return(&dto.AllocateResponse{Seed: 42}, nil)
+
+	res, err := s.service.Allocate(s.ctx, &dto.AllocateAdminRequest{StaffId: s.staff.ID.String(), DryRun: true})
+
+	s.NoError(err)
+	s.Equal(int64(42), res.Seed)
+}
+
+func (s *AdminServiceTestSuite) TestAllocate_PersistWithoutSeed() {
+	s.expectStaff()
+
+	res, err := s.service.Allocate(s.ctx, &dto.AllocateAdminRequest{StaffId: s.staff.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) TestAllocate_NotStaff() {
+	s.mockUserRepo.EXPECT().FindOne(s.staff.ID.String(), gomock.Any()).SetArg(1, model.User{Base: s.staff.Base, Role: "user"}).Return(nil)
+
+	res, err := s.service.Allocate(s.ctx, &dto.AllocateAdminRequest{StaffId: s.staff.ID.String(), Seed: 42})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *AdminServiceTestSuite) expectStaff() {
+	s.mockUserRepo.EXPECT().FindOne(s.staff.ID.String(), gomock.Any()).SetArg(1, *s.staff).Return(nil)
+}
+
+// newGroup creates a group of size members led by the first one
+func newGroup(size int) (*model.User, *model.Group) {
+	g := &model.Group{Base: model.Base{ID: uuid.New()}, Token: uuid.NewString()}
+	for i := 0; i < size; i++ {
+		g.Members = append(g.Members, &model.User{Base: model.Base{ID: uuid.New()}, GroupID: &g.ID})
+	}
+	g.LeaderID = &g.Members[0].ID
+
+	return g.Members[0], g
+}
+
+func eventTypes(events []*group.GroupEvent) []constant.GroupEventType {
+	types := make([]constant.GroupEventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
@@ -0,0 +1,15 @@
+package allocation
+
+import (
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-model/model"
+)
+
+type Allocation struct {
+	model.Base
+	GroupID    *uuid.UUID `json:"group_id" gorm:"index"`
+	SnapshotID *uuid.UUID `json:"snapshot_id"`                // the confirmation snapshot the group was allocated from
+	Baan       string     `json:"baan" gorm:"index"`          // empty if the group could not be placed in any of its choices
+	Order      int        `json:"order" gorm:"type:smallint"` // rank of the assigned baan in the group's selections, 0 if unassigned
+	Seed       int64      `json:"seed"`
+}
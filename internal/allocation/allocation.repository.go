@@ -0,0 +1,45 @@
+package allocation
+
+import (
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindCurrentSnapshots(snapshots *[]group.ConfirmSnapshot) error
+	FindAll(allocations *[]Allocation) error
+	ReplaceAll(allocations []*Allocation) error
+}
+
+type repositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repositoryImpl{
+		Db: db,
+	}
+}
+
+// FindCurrentSnapshots finds the snapshot of every group that is still confirmed
+func (r *repositoryImpl) FindCurrentSnapshots(snapshots *[]group.ConfirmSnapshot) error {
+	return r.Db.Preload("Members").Preload("Selections").Find(snapshots, "superseded_at IS NULL").Error
+}
+
+func (r *repositoryImpl) FindAll(allocations *[]Allocation) error {
+	return r.Db.Find(allocations).Error
+}
+
+func (r *repositoryImpl) ReplaceAll(allocations []*Allocation) error {
+	return r.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&Allocation{}).Error; err != nil {
+			return err
+		}
+
+		if len(allocations) == 0 {
+			return nil
+		}
+
+		return tx.Create(&allocations).Error
+	})
+}
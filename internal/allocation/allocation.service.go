@@ -0,0 +1,92 @@
+package allocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/isd-sgcu/rpkm67-backend/internal/baan"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxChoices = 5
+
+type Service interface {
+	Allocate(ctx context.Context, in *dto.AllocateRequest) (*dto.AllocateResponse, error)
+}
+
+type serviceImpl struct {
+	repo     Repository
+	baanRepo baan.Repository
+	log      *zap.Logger
+}
+
+func NewService(repo Repository, baanRepo baan.Repository, log *zap.Logger) Service {
+	return &serviceImpl{
+		repo:     repo,
+		baanRepo: baanRepo,
+		log:      log,
+	}
+}
+
+func (s *serviceImpl) Allocate(_ context.Context, in *dto.AllocateRequest) (*dto.AllocateResponse, error) {
+	seed := in.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	snapshots := []group.ConfirmSnapshot{}
+	if err := s.repo.FindCurrentSnapshots(&snapshots); err != nil {
+		s.log.Named("Allocate").Error("FindCurrentSnapshots: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find confirmed groups")
+	}
+
+	baans := []dto.Baan{}
+	if err := s.baanRepo.FindAll(&baans); err != nil {
+		s.log.Named("Allocate").Error("FindAll baan: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find baans")
+	}
+
+	capacities := make(map[string]int, len(baans))
+	for _, b := range baans {
+		if b.IsOpen {
+			capacities[b.ID] = b.Capacity
+		}
+	}
+
+	allocations := Allocate(snapshots, capacities, seed)
+
+	res := &dto.AllocateResponse{
+		Seed:         seed,
+		TotalGroups:  len(allocations),
+		ChoiceCounts: make([]int, maxChoices),
+	}
+	for _, allocation := range allocations {
+		if allocation.Baan == "" {
+			res.UnassignedGroups++
+			continue
+		}
+
+		res.AssignedGroups++
+		if allocation.Order >= 1 && allocation.Order <= maxChoices {
+			res.ChoiceCounts[allocation.Order-1]++
+		}
+	}
+
+	if in.DryRun {
+		s.log.Named("Allocate").Info("Dry run allocation", zap.Int64("seed", seed), zap.Any("result", res))
+		return res, nil
+	}
+
+	if err := s.repo.ReplaceAll(allocations); err != nil {
+		s.log.Named("Allocate").Error("ReplaceAll: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to save allocations")
+	}
+
+	s.log.Named("Allocate").Info("Allocation saved", zap.Int64("seed", seed), zap.Any("result", res))
+
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package allocation
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+)
+
+// Allocate assigns each group to a baan using random serial dictatorship: groups are
+// shuffled with the given seed, then each group in turn takes its highest ranked baan
+// that still has room for all of its members. Groups are read from their confirmation
+// snapshots, so the same snapshots and seed always yield the same result.
+func Allocate(snapshots []group.ConfirmSnapshot, capacities map[string]int, seed int64) []*Allocation {
+	ordered := make([]group.ConfirmSnapshot, len(snapshots))
+	copy(ordered, snapshots)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].GroupID.String() < ordered[j].GroupID.String()
+	})
+
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+
+	remaining := make(map[string]int, len(capacities))
+	for baan, capacity := range capacities {
+		remaining[baan] = capacity
+	}
+
+	allocations := make([]*Allocation, 0, len(ordered))
+	for _, snapshot := range ordered {
+		groupID := snapshot.GroupID
+		snapshotID := snapshot.ID
+		allocation := &Allocation{
+			GroupID:    &groupID,
+			SnapshotID: &snapshotID,
+			Seed:       seed,
+		}
+
+		selections := make([]*group.SnapshotSelection, len(snapshot.Selections))
+		copy(selections, snapshot.Selections)
+		sort.Slice(selections, func(i, j int) bool {
+			return selections[i].Order < selections[j].Order
+		})
+
+		size := len(snapshot.Members)
+		for _, selection := range selections {
+			if remaining[selection.Baan] >= size {
+				remaining[selection.Baan] -= size
+				allocation.Baan = selection.Baan
+				allocation.Order = selection.Order
+				break
+			}
+		}
+
+		allocations = append(allocations, allocation)
+	}
+
+	return allocations
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/internal/allocation"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	mock_allocation "github.com/isd-sgcu/rpkm67-backend/mocks/allocation"
+	mock_baan "github.com/isd-sgcu/rpkm67-backend/mocks/baan"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type AllocationServiceTest struct {
+	suite.Suite
+	controller   *gomock.Controller
+	mockRepo     *mock_allocation.MockRepository
+	mockBaanRepo *mock_baan.MockRepository
+	service      allocation.Service
+	baans        []dto.Baan
+	capacities   map[string]int
+	snapshots    []group.ConfirmSnapshot
+}
+
+func TestAllocationService(t *testing.T) {
+	suite.Run(t, new(AllocationServiceTest))
+}
+
+func (t *AllocationServiceTest) SetupTest() {
+	t.controller = gomock.NewController(t.T())
+	t.mockRepo = mock_allocation.NewMockRepository(t.controller)
+	t.mockBaanRepo = mock_baan.NewMockRepository(t.controller)
+	t.baans = []dto.Baan{
+		{ID: "baan1", Capacity: 2, IsOpen: true},
+		{ID: "baan2", Capacity: 2, IsOpen: true},
+		{ID: "baan3", Capacity: 10, IsOpen: false},
+	}
+	t.capacities = map[string]int{"baan1": 2, "baan2": 2}
+	t.service = allocation.NewService(t.mockRepo, t.mockBaanRepo, zap.NewNop())
+
+	// three groups of two all ranking baan1 first, only two can be placed since baan3 is closed
+	t.snapshots = []group.ConfirmSnapshot{
+		newSnapshot(2, "baan1", "baan2", "baan3"),
+		newSnapshot(2, "baan1", "baan2", "baan3"),
+		newSnapshot(2, "baan1", "baan2", "baan3"),
+	}
+}
+
+func (t *AllocationServiceTest) TearDownTest() {
+	t.controller.Finish()
+}
+
+func newSnapshot(size int, baans ...string) group.ConfirmSnapshot {
+	snapshot := group.ConfirmSnapshot{ID: uuid.New(), GroupID: uuid.New()}
+	for i := 0; i < size; i++ {
+		snapshot.Members = append(snapshot.Members, &group.SnapshotMember{SnapshotID: snapshot.ID, UserID: uuid.New()})
+	}
+	for i, baan := range baans {
+		snapshot.Selections = append(snapshot.Selections, &group.SnapshotSelection{SnapshotID: snapshot.ID, Baan: baan, Order: i + 1})
+	}
+
+	return snapshot
+}
+
+func (t *AllocationServiceTest) TestAllocateRespectsMemberCapacity() {
+	snapshots := []group.ConfirmSnapshot{
+		newSnapshot(3, "baan1", "baan2"),
+		newSnapshot(1, "baan1"),
+	}
+	capacities := map[string]int{"baan1": 2, "baan2": 3}
+
+	allocations := allocation.Allocate(snapshots, capacities, 1)
+
+	used := map[string]int{}
+	for _, a := range allocations {
+		for _, g := range snapshots {
+			if g.GroupID == *a.GroupID && a.Baan != "" {
+				used[a.Baan] += len(g.Members)
+			}
+		}
+	}
+	t.LessOrEqual(used["baan1"], 2)
+	t.LessOrEqual(used["baan2"], 3)
+	t.Len(allocations, 2)
+}
+
+func (t *AllocationServiceTest) TestAllocateDeterministic() {
+	first := allocation.Allocate(t.snapshots, t.capacities, 42)
+	second := allocation.Allocate(t.snapshots, t.capacities, 42)
+
+	t.Equal(first, second)
+}
+
+func (t *AllocationServiceTest) TestAllocateDryRun() {
+	t.mockRepo.EXPECT().FindCurrentSnapshots(gomock.Any()).SetArg(0, t.snapshots).Return(nil)
+	t.mockBaanRepo.EXPECT().FindAll(gomock.Any()).SetArg(0, t.baans).Return(nil)
+
+	res, err := t.service.Allocate(context.Background(), &dto.AllocateRequest{Seed: 42, DryRun: true})
+
+	t.Nil(err)
+	t.Equal(int64(42), res.Seed)
+	t.Equal(3, res.TotalGroups)
+	t.Equal(2, res.AssignedGroups)
+	t.Equal(1, res.UnassignedGroups)
+	t.Equal([]int{1, 1, 0, 0, 0}, res.ChoiceCounts)
+}
+
+func (t *AllocationServiceTest) TestAllocateSaved() {
+	t.mockRepo.EXPECT().FindCurrentSnapshots(gomock.Any()).SetArg(0, t.snapshots).Return(nil)
+	t.mockBaanRepo.EXPECT().FindAll(gomock.Any()).SetArg(0, t.baans).Return(nil)
+	t.mockRepo.EXPECT().ReplaceAll(gomock.Len(3)).Return(nil)
+
+	res, err := t.service.Allocate(context.Background(), &dto.AllocateRequest{Seed: 42})
+
+	t.Nil(err)
+	t.Equal(2, res.AssignedGroups)
+}
+
+func (t *AllocationServiceTest) TestAllocateFindGroupsError() {
+	t.mockRepo.EXPECT().FindCurrentSnapshots(gomock.Any()).Return(errors.New("error"))
+
+	res, err := t.service.Allocate(context.Background(), &dto.AllocateRequest{Seed: 42})
+
+	t.Nil(res)
+	t.Equal(codes.Internal, status.Code(err))
+}
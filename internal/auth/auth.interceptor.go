@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const defaultMaxSkew = 5 * time.Minute
+
+// NewUnaryInterceptor authenticates the caller from the metadata signed by the gateway and
+// enforces the policy of the called method. The caller is then available through CallerFromContext.
+func NewUnaryInterceptor(conf *config.AuthConfig, policies map[string]Policy, log *zap.Logger) grpc.UnaryServerInterceptor {
+	maxSkew := time.Duration(conf.MaxSkew) * time.Second
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxSkew
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		policy, ok := policies[info.FullMethod]
+		if !ok {
+			log.Named("UnaryInterceptor").Error("No policy for method", zap.String("method", info.FullMethod))
+			return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+		}
+
+		if policy.Public {
+			return handler(ctx, req)
+		}
+
+		caller, err := authenticate(ctx, conf.Secret, maxSkew, info.FullMethod)
+		if err != nil {
+			log.Named("UnaryInterceptor").Error("authenticate: ", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, err
+		}
+
+		if err := authorize(caller, policy, req); err != nil {
+			log.Named("UnaryInterceptor").Error("authorize: ", zap.String("method", info.FullMethod), zap.String("user_id", caller.UserId), zap.Error(err))
+			return nil, err
+		}
+
+		return handler(WithCaller(ctx, caller), req)
+	}
+}
+
+func authenticate(ctx context.Context, secret string, maxSkew time.Duration, method string) (*Caller, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	userId := firstValue(md, UserIdKey)
+	role := firstValue(md, RoleKey)
+	timestamp := firstValue(md, TimestampKey)
+	signature := firstValue(md, SignatureKey)
+	if userId == "" || role == "" || timestamp == "" || signature == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing caller identity")
+	}
+
+	expected := Sign(secret, userId, role, timestamp, method)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, status.Error(codes.Unauthenticated, "invalid signature")
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid timestamp")
+	}
+	if skew := time.Since(time.Unix(signedAt, 0)); skew > maxSkew || skew < -maxSkew {
+		return nil, status.Error(codes.Unauthenticated, "signature expired")
+	}
+
+	switch constant.Role(role) {
+	case constant.USER, constant.STAFF:
+	default:
+		return nil, status.Error(codes.Unauthenticated, "unknown role")
+	}
+
+	return &Caller{UserId: userId, Role: constant.Role(role)}, nil
+}
+
+func authorize(caller *Caller, policy Policy, req interface{}) error {
+	if policy.Role == constant.STAFF && !caller.IsStaff() {
+		return status.Error(codes.PermissionDenied, "staff only")
+	}
+
+	if caller.IsStaff() {
+		return nil
+	}
+
+	for _, field := range policy.Self {
+		if requestField(req, field) != caller.UserId {
+			return status.Errorf(codes.PermissionDenied, "%s does not match the caller", field)
+		}
+	}
+
+	return nil
+}
+
+// requestField reads a string field of a request, by its proto name for proto requests, e.g. userId,
+// and by its json name for the dto requests of the JSON services, e.g. leader_id
+func requestField(req interface{}, name string) string {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return dtoField(req, name)
+	}
+
+	m := msg.ProtoReflect()
+	field := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if field == nil {
+		return ""
+	}
+
+	return m.Get(field).String()
+}
+
+func dtoField(req interface{}, name string) string {
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if tag == name && v.Field(i).Kind() == reflect.String {
+			return v.Field(i).String()
+		}
+	}
+
+	return ""
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
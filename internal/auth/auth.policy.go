@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	countProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/count/v1"
+	groupProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
+	pinProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/pin/v1"
+	selectionProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
+	stampProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/stamp/v1"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Policy struct {
+	Public bool          // no caller needed
+	Role   constant.Role // required role, empty allows any authenticated caller
+	Self   []string      // request fields that must equal the caller's id unless the caller is staff
+}
+
+// Policies lists every RPC the server exposes. Calls to methods missing here are denied.
+var Policies = map[string]Policy{
+	grpc_health_v1.Health_Check_FullMethodName: {Public: true},
+
+	pinProto.PinService_FindAll_FullMethodName:  {Role: constant.STAFF},
+	pinProto.PinService_ResetPin_FullMethodName: {Role: constant.STAFF},
+	pinProto.PinService_CheckPin_FullMethodName: {},
+
+	stampProto.StampService_FindByUserId_FullMethodName:  {Self: []string{"userId"}},
+	stampProto.StampService_StampByUserId_FullMethodName: {Self: []string{"userId"}},
+
+	groupProto.GroupService_FindByUserId_FullMethodName:  {Self: []string{"userId"}},
+	groupProto.GroupService_FindByToken_FullMethodName:   {},
+	groupProto.GroupService_UpdateConfirm_FullMethodName: {Self: []string{"leaderId"}},
+	groupProto.GroupService_Join_FullMethodName:          {Self: []string{"userId"}},
+	groupProto.GroupService_DeleteMember_FullMethodName:  {Self: []string{"leaderId"}},
+	groupProto.GroupService_Leave_FullMethodName:         {Self: []string{"userId"}},
+
+	// the service also checks that leader_id leads the group
+	constant.GroupJSONService_ListJoinRequests_FullMethodName:   {Self: []string{"leader_id"}},
+	constant.GroupJSONService_AcceptJoinRequest_FullMethodName:  {Self: []string{"leader_id"}},
+	constant.GroupJSONService_RejectJoinRequest_FullMethodName:  {Self: []string{"leader_id"}},
+	constant.GroupJSONService_TransferLeadership_FullMethodName: {Self: []string{"leader_id"}},
+	constant.GroupJSONService_RotateToken_FullMethodName:        {Self: []string{"leader_id"}},
+	constant.GroupJSONService_Disband_FullMethodName:            {Self: []string{"leader_id"}},
+
+	// selections are keyed by group, so the service checks that the caller is a member of group_id
+	selectionProto.SelectionService_Create_FullMethodName:              {},
+	selectionProto.SelectionService_FindByGroupId_FullMethodName:       {},
+	selectionProto.SelectionService_Update_FullMethodName:              {},
+	selectionProto.SelectionService_Delete_FullMethodName:              {},
+	selectionProto.SelectionService_CountByBaanId_FullMethodName:       {},
+	constant.SelectionJSONService_ReplaceAll_FullMethodName:            {},
+	constant.SelectionJSONService_CountCapacityByBaanId_FullMethodName: {},
+
+	countProto.CountService_FindAll_FullMethodName: {Role: constant.STAFF},
+	countProto.CountService_Create_FullMethodName:  {Role: constant.STAFF},
+}
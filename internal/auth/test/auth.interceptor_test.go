@@ -0,0 +1,201 @@
+package test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	groupProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
+	pinProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/pin/v1"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type AuthInterceptorTest struct {
+	suite.Suite
+	conf        *config.AuthConfig
+	interceptor grpc.UnaryServerInterceptor
+	caller      *auth.Caller
+	called      bool
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	suite.Run(t, new(AuthInterceptorTest))
+}
+
+func (t *AuthInterceptorTest) SetupTest() {
+	t.conf = &config.AuthConfig{Secret: "secret", MaxSkew: 60}
+	t.interceptor = auth.NewUnaryInterceptor(t.conf, auth.Policies, zap.NewNop())
+	t.caller = nil
+	t.called = false
+}
+
+func (t *AuthInterceptorTest) handler(ctx context.Context, req interface{}) (interface{}, error) {
+	t.called = true
+	t.caller, _ = auth.CallerFromContext(ctx)
+	return "ok", nil
+}
+
+func (t *AuthInterceptorTest) signedCtx(userId string, role constant.Role, signedAt time.Time, method string) context.Context {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		auth.UserIdKey, userId,
+		auth.RoleKey, role.String(),
+		auth.TimestampKey, timestamp,
+		auth.SignatureKey, auth.Sign(t.conf.Secret, userId, role.String(), timestamp, method),
+	))
+}
+
+func (t *AuthInterceptorTest) call(ctx context.Context, method string, req interface{}) error {
+	_, err := t.interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, t.handler)
+	return err
+}
+
+func (t *AuthInterceptorTest) TestSelfSuccess() {
+	method := groupProto.GroupService_Leave_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+
+	err := t.call(ctx, method, &groupProto.LeaveGroupRequest{UserId: "user-1"})
+
+	t.Nil(err)
+	t.True(t.called)
+	t.Equal(&auth.Caller{UserId: "user-1", Role: constant.USER}, t.caller)
+}
+
+func (t *AuthInterceptorTest) TestSelfMismatch() {
+	method := groupProto.GroupService_Leave_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+
+	err := t.call(ctx, method, &groupProto.LeaveGroupRequest{UserId: "user-2"})
+
+	t.Equal(codes.PermissionDenied, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestSelfDTOSuccess() {
+	method := constant.GroupJSONService_AcceptJoinRequest_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+
+	err := t.call(ctx, method, &dto.AcceptJoinRequestGroupRequest{LeaderId: "user-1", RequestId: "request-1"})
+
+	t.Nil(err)
+	t.True(t.called)
+}
+
+func (t *AuthInterceptorTest) TestSelfDTOMismatch() {
+	method := constant.GroupJSONService_AcceptJoinRequest_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+
+	err := t.call(ctx, method, &dto.AcceptJoinRequestGroupRequest{LeaderId: "user-2", RequestId: "request-1"})
+
+	t.Equal(codes.PermissionDenied, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestDisbandOtherLeader() {
+	method := constant.GroupJSONService_Disband_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+
+	err := t.call(ctx, method, &dto.DisbandGroupRequest{LeaderId: "user-2"})
+
+	t.Equal(codes.PermissionDenied, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestSelfStaffBypass() {
+	method := groupProto.GroupService_DeleteMember_FullMethodName
+	ctx := t.signedCtx("staff-1", constant.STAFF, time.Now(), method)
+
+	err := t.call(ctx, method, &groupProto.DeleteMemberGroupRequest{LeaderId: "user-1", UserId: "user-2"})
+
+	t.Nil(err)
+	t.True(t.called)
+}
+
+func (t *AuthInterceptorTest) TestStaffOnlyDenied() {
+	method := pinProto.PinService_ResetPin_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+
+	err := t.call(ctx, method, &pinProto.ResetPinRequest{ActivityId: "workshop-1"})
+
+	t.Equal(codes.PermissionDenied, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestStaffOnlySuccess() {
+	method := pinProto.PinService_ResetPin_FullMethodName
+	ctx := t.signedCtx("staff-1", constant.STAFF, time.Now(), method)
+
+	err := t.call(ctx, method, &pinProto.ResetPinRequest{ActivityId: "workshop-1"})
+
+	t.Nil(err)
+	t.True(t.called)
+}
+
+func (t *AuthInterceptorTest) TestInvalidSignature() {
+	method := pinProto.PinService_ResetPin_FullMethodName
+	// signed as a user, sent as staff
+	ctx := t.signedCtx("user-1", constant.USER, time.Now(), method)
+	md, _ := metadata.FromIncomingContext(ctx)
+	md.Set(auth.RoleKey, constant.STAFF.String())
+	ctx = metadata.NewIncomingContext(context.Background(), md)
+
+	err := t.call(ctx, method, &pinProto.ResetPinRequest{ActivityId: "workshop-1"})
+
+	t.Equal(codes.Unauthenticated, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestSignatureForOtherMethod() {
+	ctx := t.signedCtx("staff-1", constant.STAFF, time.Now(), pinProto.PinService_FindAll_FullMethodName)
+
+	err := t.call(ctx, pinProto.PinService_ResetPin_FullMethodName, &pinProto.ResetPinRequest{ActivityId: "workshop-1"})
+
+	t.Equal(codes.Unauthenticated, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestExpiredSignature() {
+	method := pinProto.PinService_CheckPin_FullMethodName
+	ctx := t.signedCtx("user-1", constant.USER, time.Now().Add(-2*time.Minute), method)
+
+	err := t.call(ctx, method, &pinProto.CheckPinRequest{ActivityId: "workshop-1", Code: "123456"})
+
+	t.Equal(codes.Unauthenticated, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestMissingMetadata() {
+	err := t.call(context.Background(), pinProto.PinService_CheckPin_FullMethodName, &pinProto.CheckPinRequest{})
+
+	t.Equal(codes.Unauthenticated, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestUnknownMethod() {
+	method := "/unknown.v1.UnknownService/Call"
+	ctx := t.signedCtx("staff-1", constant.STAFF, time.Now(), method)
+
+	err := t.call(ctx, method, nil)
+
+	t.Equal(codes.PermissionDenied, status.Code(err))
+	t.False(t.called)
+}
+
+func (t *AuthInterceptorTest) TestPublicMethod() {
+	err := t.call(context.Background(), grpc_health_v1.Health_Check_FullMethodName, &grpc_health_v1.HealthCheckRequest{})
+
+	t.Nil(err)
+	t.True(t.called)
+	t.Nil(t.caller)
+}
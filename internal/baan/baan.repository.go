@@ -0,0 +1,72 @@
+package baan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+)
+
+var ErrBaanNotFound = errors.New("baan not found")
+
+type Repository interface {
+	FindAll(baans *[]dto.Baan) error
+	FindOne(id string, baan *dto.Baan) error
+}
+
+type repositoryImpl struct {
+	baans []dto.Baan
+	byId  map[string]dto.Baan
+}
+
+// NewRepository loads the baan registry from the seed file in conf. The registry
+// is read once at startup and kept in memory.
+func NewRepository(conf *config.BaanConfig) (Repository, error) {
+	data, err := os.ReadFile(conf.SeedFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baan seed file: %w", err)
+	}
+
+	baans := []dto.Baan{}
+	if err := json.Unmarshal(data, &baans); err != nil {
+		return nil, fmt.Errorf("failed to parse baan seed file: %w", err)
+	}
+
+	return NewRepositoryFromList(baans)
+}
+
+func NewRepositoryFromList(baans []dto.Baan) (Repository, error) {
+	byId := make(map[string]dto.Baan, len(baans))
+	for _, b := range baans {
+		if b.ID == "" {
+			return nil, errors.New("baan id must not be empty")
+		}
+		if _, ok := byId[b.ID]; ok {
+			return nil, fmt.Errorf("duplicate baan id: %s", b.ID)
+		}
+		byId[b.ID] = b
+	}
+
+	return &repositoryImpl{
+		baans: baans,
+		byId:  byId,
+	}, nil
+}
+
+func (r *repositoryImpl) FindAll(baans *[]dto.Baan) error {
+	*baans = append((*baans)[:0], r.baans...)
+	return nil
+}
+
+func (r *repositoryImpl) FindOne(id string, baan *dto.Baan) error {
+	b, ok := r.byId[id]
+	if !ok {
+		return ErrBaanNotFound
+	}
+	*baan = b
+
+	return nil
+}
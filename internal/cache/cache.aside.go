@@ -0,0 +1,329 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// Aside is a cache-aside layer over Repository. Entries are stored under a versioned key and
+// Invalidate bumps the version instead of deleting the entry, so a reader that loaded from the
+// database before a write committed can only store its result under the old version, which is
+// never read again. Cache errors are logged and the value is loaded from the database instead.
+//
+// A miss is loaded once per key: concurrent callers in the process share one load, and across
+// instances the first to take a Redis lock loads while the others wait for its entry. With early
+// refresh enabled an entry is reloaded shortly before it expires, the closer to expiry and the
+// slower the load the likelier, so a popular key is refreshed by one caller instead of expiring
+// under all of them.
+type Aside interface {
+	// Load reads key into value, calling load to fill value and caching it on a miss. A caller
+	// whose context ends stops waiting, a load it shares with others carries on for them.
+	Load(ctx context.Context, key string, value interface{}, ttl int, load func() error) error
+	// Invalidate makes the current entries of keys unreachable. A key whose version cannot be bumped
+	// has its current entry deleted instead, an error means some entries are left to expire.
+	Invalidate(ctx context.Context, keys ...string) error
+	// InvalidatePrefix deletes every entry under prefix. Unlike Invalidate it does not stop a
+	// concurrent reader from storing what it loaded before the change.
+	InvalidatePrefix(ctx context.Context, prefix string) error
+}
+
+const (
+	defaultLockTTL   = 5
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// entry is a cached value with what early refresh needs to decide when to reload it. It is stored
+// as the two numbers followed by the value encoded with the codec of its key.
+type entry struct {
+	Value     []byte
+	ExpiresAt int64 // unix milliseconds
+	Delta     int64 // milliseconds the load took
+}
+
+const entryHeaderSize = 16
+
+func (e *entry) encode() Raw {
+	data := make([]byte, entryHeaderSize, entryHeaderSize+len(e.Value))
+	binary.BigEndian.PutUint64(data[0:8], uint64(e.ExpiresAt))
+	binary.BigEndian.PutUint64(data[8:16], uint64(e.Delta))
+
+	return append(data, e.Value...)
+}
+
+func decodeEntry(data []byte) (*entry, error) {
+	if len(data) < entryHeaderSize {
+		return nil, fmt.Errorf("cache entry is %d bytes, shorter than its header", len(data))
+	}
+
+	return &entry{
+		Value:     data[entryHeaderSize:],
+		ExpiresAt: int64(binary.BigEndian.Uint64(data[0:8])),
+		Delta:     int64(binary.BigEndian.Uint64(data[8:16])),
+	}, nil
+}
+
+type asideImpl struct {
+	repo    Repository
+	codecs  Codecs
+	conf    *config.CacheConfig
+	loading singleflight.Group
+	metrics *Metrics
+	log     *zap.Logger
+}
+
+func NewAside(repo Repository, codecs Codecs, conf *config.CacheConfig, metrics *Metrics, log *zap.Logger) Aside {
+	return &asideImpl{
+		repo:    repo,
+		codecs:  codecs,
+		conf:    conf,
+		metrics: metrics,
+		log:     log,
+	}
+}
+
+func (a *asideImpl) Load(ctx context.Context, key string, value interface{}, ttl int, load func() error) error {
+	start := time.Now()
+
+	// the version has to be read before loading, see Aside
+	version, err := a.version(ctx, key)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		a.log.Named("Load").Warn("version: ", zap.String("key", key), zap.Error(err))
+		a.metrics.failed(key, "version")
+		defer a.metrics.observe(key, resultBypass, start)
+		return load()
+	}
+	vKey := versionedKey(key, version)
+
+	cached, err := a.get(ctx, vKey)
+	if err != nil {
+		a.log.Named("Load").Warn("get: ", zap.String("key", key), zap.Error(err))
+		a.metrics.failed(key, "get")
+	}
+	result := resultMiss
+	if cached != nil {
+		result = resultRefresh
+	}
+	if cached != nil && !a.refreshEarly(cached) {
+		err := a.codecs.Unmarshal(vKey, cached.Value, value)
+		if err == nil {
+			a.metrics.observe(key, resultHit, start)
+			return nil
+		}
+		// an entry written in another format is loaded again
+		a.log.Named("Load").Warn("Unmarshal: ", zap.String("key", key), zap.Error(err))
+		a.metrics.failed(key, "decode")
+		cached = nil
+		result = resultMiss
+	}
+	defer func() { a.metrics.observe(key, result, start) }()
+
+	// the fill is shared, so it must not end with the context of the caller that started it
+	fillCtx := context.WithoutCancel(ctx)
+	loaded := false
+	shared := a.loading.DoChan(vKey, func() (interface{}, error) {
+		data, filled, err := a.fill(fillCtx, vKey, value, ttl, load, cached)
+		loaded = filled
+		return data, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-shared:
+		if res.Err != nil && cached != nil {
+			// the entry being refreshed early has not expired yet, so it is served instead
+			a.log.Named("Load").Warn("refresh: ", zap.String("key", key), zap.Error(res.Err))
+			a.metrics.failed(key, "refresh")
+			return a.codecs.Unmarshal(vKey, cached.Value, value)
+		}
+		if res.Err != nil {
+			return res.Err
+		}
+		// only the caller that ran load has value filled already
+		if loaded {
+			return nil
+		}
+
+		return a.codecs.Unmarshal(vKey, res.Val.([]byte), value)
+	}
+}
+
+// fill loads the entry under the refill lock. Without the lock it waits for the holder's entry,
+// or returns the stale entry when refreshing early, and loads itself once the lock expires.
+func (a *asideImpl) fill(ctx context.Context, vKey string, value interface{}, ttl int, load func() error, stale *entry) (data []byte, filled bool, err error) {
+	lockTTL := a.conf.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = defaultLockTTL
+	}
+	deadline := time.Now().Add(time.Duration(lockTTL) * time.Second)
+
+	for {
+		locked, err := a.repo.SetValueNX(ctx, lockKey(vKey), true, lockTTL)
+		if err != nil {
+			a.log.Named("fill").Warn("SetValueNX: ", zap.String("key", vKey), zap.Error(err))
+			a.metrics.failed(vKey, "lock")
+			locked = true
+		}
+		if locked || time.Now().After(deadline) {
+			break
+		}
+		if stale != nil {
+			return stale.Value, false, nil
+		}
+
+		time.Sleep(lockPollInterval)
+
+		cached, err := a.get(ctx, vKey)
+		if err != nil {
+			a.log.Named("fill").Warn("get: ", zap.String("key", vKey), zap.Error(err))
+			a.metrics.failed(vKey, "get")
+		}
+		if cached != nil {
+			return cached.Value, false, nil
+		}
+	}
+
+	// a load outliving the lock may release a later holder's lock, which only costs an extra load
+	defer func() {
+		if err := a.repo.DeleteValue(ctx, lockKey(vKey)); err != nil {
+			a.log.Named("fill").Warn("DeleteValue lock: ", zap.String("key", vKey), zap.Error(err))
+			a.metrics.failed(vKey, "unlock")
+		}
+	}()
+
+	start := time.Now()
+	if err := load(); err != nil {
+		return nil, false, err
+	}
+
+	data, err = a.codecs.Marshal(vKey, value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	loadedAt := time.Now()
+	cached := &entry{
+		Value:     data,
+		ExpiresAt: loadedAt.Add(time.Duration(ttl) * time.Second).UnixMilli(),
+		Delta:     loadedAt.Sub(start).Milliseconds(),
+	}
+	if err := a.repo.SetValue(ctx, vKey, cached.encode(), ttl); err != nil {
+		a.log.Named("fill").Warn("SetValue: ", zap.String("key", vKey), zap.Error(err))
+		a.metrics.failed(vKey, "set")
+	}
+
+	return data, true, nil
+}
+
+// refreshEarly decides whether to reload an entry before it expires, following the XFetch algorithm
+func (a *asideImpl) refreshEarly(cached *entry) bool {
+	if a.conf.EarlyRefreshBeta <= 0 {
+		return false
+	}
+
+	gap := float64(cached.Delta) * a.conf.EarlyRefreshBeta * -math.Log(rand.Float64())
+
+	return float64(time.Now().UnixMilli())+gap >= float64(cached.ExpiresAt)
+}
+
+// get reads the entry under vKey, a missing entry is nil without an error
+func (a *asideImpl) get(ctx context.Context, vKey string) (*entry, error) {
+	var data Raw
+	if err := a.repo.GetValue(ctx, vKey, &data); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return decodeEntry(data)
+}
+
+func (a *asideImpl) Invalidate(ctx context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		if err := a.invalidate(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("failed to invalidate %s: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// invalidate bumps the version of key, retrying once. When that fails the current entry is deleted
+// instead, which a reader that loaded before the change may still refill, and when that fails too
+// the entry is read until its ttl ends.
+func (a *asideImpl) invalidate(ctx context.Context, key string) error {
+	_, err := a.repo.Increment(ctx, versionKey(key))
+	if err == nil {
+		return nil
+	}
+	a.log.Named("Invalidate").Warn("Increment: ", zap.String("key", key), zap.Error(err))
+
+	if _, err = a.repo.Increment(ctx, versionKey(key)); err == nil {
+		return nil
+	}
+	a.log.Named("Invalidate").Warn("Increment retry: ", zap.String("key", key), zap.Error(err))
+	a.metrics.failed(key, "invalidate")
+
+	var version int64
+	if verr := a.repo.GetValue(ctx, versionKey(key), &version); verr != nil && !errors.Is(verr, redis.Nil) {
+		return errors.Join(err, verr)
+	}
+	if derr := a.repo.DeleteValue(ctx, versionedKey(key, version)); derr != nil {
+		return errors.Join(err, derr)
+	}
+
+	return nil
+}
+
+func (a *asideImpl) InvalidatePrefix(ctx context.Context, prefix string) error {
+	return a.repo.DeleteByPrefix(ctx, prefix)
+}
+
+// version reads the version of key, creating it at 0 when missing. A missing version would be read
+// from Redis on every Load, a stored one can be kept by the local tier until Invalidate bumps it.
+func (a *asideImpl) version(ctx context.Context, key string) (int64, error) {
+	var version int64
+	err := a.repo.GetValue(ctx, versionKey(key), &version)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return 0, err
+	}
+
+	// a concurrent Invalidate wins, its version only makes this load's entry unreachable
+	if _, err := a.repo.SetValueNX(ctx, versionKey(key), version, 0); err != nil {
+		a.log.Named("version").Warn("SetValueNX: ", zap.String("key", key), zap.Error(err))
+		a.metrics.failed(key, "version")
+	}
+
+	return version, nil
+}
+
+// versionKey holds the current version of key, it has no ttl so it outlives every entry of key
+func versionKey(key string) string {
+	return "version:" + key
+}
+
+func lockKey(vKey string) string {
+	return "lock:" + vKey
+}
+
+func versionedKey(key string, version int64) string {
+	return fmt.Sprintf("%s@%d", key, version)
+}
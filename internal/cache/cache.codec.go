@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec turns cached values into bytes and back
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, value interface{}) error
+}
+
+// Codecs picks the codec of a key by its longest matching prefix, keys without one use JSON.
+// Version and lock keys must stay on JSON, Increment relies on the version being a plain number.
+type Codecs map[string]Codec
+
+// Raw is a value that is already encoded, it is stored and read as is
+type Raw []byte
+
+func (c Codecs) For(key string) Codec {
+	var codec Codec = JSONCodec{}
+	matched := -1
+	for prefix, prefixCodec := range c {
+		if strings.HasPrefix(key, prefix) && len(prefix) > matched {
+			codec = prefixCodec
+			matched = len(prefix)
+		}
+	}
+
+	return codec
+}
+
+func (c Codecs) Marshal(key string, value interface{}) ([]byte, error) {
+	if raw, ok := value.(Raw); ok {
+		return raw, nil
+	}
+
+	return c.For(key).Marshal(value)
+}
+
+func (c Codecs) Unmarshal(key string, data []byte, value interface{}) error {
+	if raw, ok := value.(*Raw); ok {
+		*raw = data
+		return nil
+	}
+
+	return c.For(key).Unmarshal(data, value)
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec) Unmarshal(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+// ProtoCodec encodes proto messages in the protobuf binary format
+type ProtoCodec struct{}
+
+func (ProtoCodec) Marshal(value interface{}) ([]byte, error) {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("proto codec cannot marshal %T", value)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, value interface{}) error {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return fmt.Errorf("proto codec cannot unmarshal into %T", value)
+	}
+
+	return proto.Unmarshal(data, msg)
+}
+
+type Compression string
+
+const (
+	NoCompression Compression = ""
+	Snappy        Compression = "snappy"
+	Zstd          Compression = "zstd"
+)
+
+// the first byte of compressed data. Neither can start JSON, and in protobuf they would be field 0,
+// which is invalid, so data below the threshold is stored without a marker.
+const (
+	snappyMarker byte = 0x01
+	zstdMarker   byte = 0x02
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+// Compress compresses what codec encodes once it reaches threshold bytes. Data is decoded by its
+// marker, so entries written with another compression or threshold still read.
+func Compress(codec Codec, compression Compression, threshold int) Codec {
+	if compression == NoCompression {
+		return codec
+	}
+
+	return &compressedCodec{codec: codec, compression: compression, threshold: threshold}
+}
+
+type compressedCodec struct {
+	codec       Codec
+	compression Compression
+	threshold   int
+}
+
+func (c *compressedCodec) Marshal(value interface{}) ([]byte, error) {
+	data, err := c.codec.Marshal(value)
+	if err != nil || len(data) < c.threshold {
+		return data, err
+	}
+
+	switch c.compression {
+	case Snappy:
+		return append([]byte{snappyMarker}, snappy.Encode(nil, data)...), nil
+	case Zstd:
+		initZstd()
+		return zstdEncoder.EncodeAll(data, []byte{zstdMarker}), nil
+	default:
+		return nil, fmt.Errorf("unknown compression %q", c.compression)
+	}
+}
+
+func (c *compressedCodec) Unmarshal(data []byte, value interface{}) error {
+	if len(data) == 0 {
+		return c.codec.Unmarshal(data, value)
+	}
+
+	var err error
+	switch data[0] {
+	case snappyMarker:
+		data, err = snappy.Decode(nil, data[1:])
+	case zstdMarker:
+		initZstd()
+		data, err = zstdDecoder.DecodeAll(data[1:], nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decompress: %w", err)
+	}
+
+	return c.codec.Unmarshal(data, value)
+}
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		// neither fails without options
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// invalidateChannel carries the keys written by any replica so the others drop their local copies
+const invalidateChannel = "cache:invalidate"
+
+const defaultLocalTTL = 10
+
+// localRepository keeps the encoded values of recently used keys in a size limited LRU in front of
+// another Repository. Writes go through to next and are published, every replica drops the written
+// key when the message arrives. A missed message is bounded by the local ttl.
+type localRepository struct {
+	id     string // tells the replica's own messages apart
+	next   Repository
+	client *redis.Client
+	codecs Codecs
+	conf   *config.CacheConfig
+	mu     sync.Mutex
+	items  map[string]*list.Element
+	order  *list.List // front is the most recently used
+	log    *zap.Logger
+}
+
+type localItem struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+type invalidation struct {
+	Origin string `json:"origin"`
+	Key    string `json:"key,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+func NewLocalRepository(next Repository, client *redis.Client, codecs Codecs, conf *config.CacheConfig, log *zap.Logger) Repository {
+	r := &localRepository{
+		id:     uuid.NewString(),
+		next:   next,
+		client: client,
+		codecs: codecs,
+		conf:   conf,
+		items:  make(map[string]*list.Element),
+		order:  list.New(),
+		log:    log,
+	}
+	r.subscribe()
+
+	return r
+}
+
+func (r *localRepository) SetValue(ctx context.Context, key string, value interface{}, ttl int) error {
+	data, err := r.codecs.Marshal(key, value)
+	if err != nil {
+		return err
+	}
+
+	if err := r.next.SetValue(ctx, key, Raw(data), ttl); err != nil {
+		return err
+	}
+
+	r.publish(ctx, invalidation{Key: key})
+	r.store(key, data, ttl)
+
+	return nil
+}
+
+func (r *localRepository) SetValueNX(ctx context.Context, key string, value interface{}, ttl int) (bool, error) {
+	data, err := r.codecs.Marshal(key, value)
+	if err != nil {
+		return false, err
+	}
+
+	set, err := r.next.SetValueNX(ctx, key, Raw(data), ttl)
+	if err != nil || !set {
+		return set, err
+	}
+
+	r.publish(ctx, invalidation{Key: key})
+	r.store(key, data, ttl)
+
+	return true, nil
+}
+
+func (r *localRepository) GetValue(ctx context.Context, key string, value interface{}) error {
+	if data, ok := r.load(key); ok {
+		return r.codecs.Unmarshal(key, data, value)
+	}
+
+	var data Raw
+	if err := r.next.GetValue(ctx, key, &data); err != nil {
+		return err
+	}
+	r.store(key, data, 0)
+
+	return r.codecs.Unmarshal(key, data, value)
+}
+
+func (r *localRepository) DeleteValue(ctx context.Context, key string) error {
+	if err := r.next.DeleteValue(ctx, key); err != nil {
+		return err
+	}
+
+	r.drop(invalidation{Key: key})
+	r.publish(ctx, invalidation{Key: key})
+
+	return nil
+}
+
+func (r *localRepository) DeleteByPrefix(ctx context.Context, prefix string) error {
+	if err := r.next.DeleteByPrefix(ctx, prefix); err != nil {
+		return err
+	}
+
+	r.drop(invalidation{Prefix: prefix})
+	r.publish(ctx, invalidation{Prefix: prefix})
+
+	return nil
+}
+
+func (r *localRepository) Increment(ctx context.Context, key string) (int64, error) {
+	value, err := r.next.Increment(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	r.drop(invalidation{Key: key})
+	r.publish(ctx, invalidation{Key: key})
+
+	return value, nil
+}
+
+// load returns the local copy of key, expired copies are dropped
+func (r *localRepository) load(key string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, ok := r.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := element.Value.(*localItem)
+	if time.Now().After(item.expiresAt) {
+		r.order.Remove(element)
+		delete(r.items, key)
+		return nil, false
+	}
+	r.order.MoveToFront(element)
+
+	return item.data, true
+}
+
+// store keeps a local copy of key for ttl seconds, capped by the local ttl, evicting the least recently used
+// key when full. A ttl of 0 uses the local ttl.
+func (r *localRepository) store(key string, data []byte, ttl int) {
+	localTTL := r.conf.LocalTTL
+	if localTTL <= 0 {
+		localTTL = defaultLocalTTL
+	}
+	if ttl <= 0 || ttl > localTTL {
+		ttl = localTTL
+	}
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Second)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if element, ok := r.items[key]; ok {
+		element.Value = &localItem{key: key, data: data, expiresAt: expiresAt}
+		r.order.MoveToFront(element)
+		return
+	}
+
+	r.items[key] = r.order.PushFront(&localItem{key: key, data: data, expiresAt: expiresAt})
+	for r.order.Len() > r.conf.LocalSize {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.items, oldest.Value.(*localItem).key)
+	}
+}
+
+func (r *localRepository) drop(in invalidation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if in.Prefix == "" {
+		if element, ok := r.items[in.Key]; ok {
+			r.order.Remove(element)
+			delete(r.items, in.Key)
+		}
+		return
+	}
+
+	for key, element := range r.items {
+		if strings.HasPrefix(key, in.Prefix) {
+			r.order.Remove(element)
+			delete(r.items, key)
+		}
+	}
+}
+
+func (r *localRepository) publish(ctx context.Context, in invalidation) {
+	ctx, cancel := context.WithTimeout(ctx, timeout(r.conf))
+	defer cancel()
+
+	in.Origin = r.id
+	msg, err := json.Marshal(in)
+	if err != nil {
+		r.log.Named("publish").Warn("Marshal: ", zap.Error(err))
+		return
+	}
+
+	if err := r.client.Publish(ctx, invalidateChannel, msg).Err(); err != nil {
+		r.log.Named("publish").Warn("Publish: ", zap.Error(err))
+	}
+}
+
+// subscribe drops the keys other replicas write for as long as the client is open
+func (r *localRepository) subscribe() {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout(r.conf))
+	defer cancel()
+
+	pubsub := r.client.Subscribe(context.Background(), invalidateChannel)
+	// wait for the subscription so no write after the constructor returns is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		r.log.Named("subscribe").Warn("Receive: ", zap.Error(err))
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			in := invalidation{}
+			if err := json.Unmarshal([]byte(msg.Payload), &in); err != nil {
+				r.log.Named("subscribe").Warn("Unmarshal: ", zap.Error(err))
+				continue
+			}
+			if in.Origin != r.id {
+				r.drop(in)
+			}
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Results of Load
+const (
+	resultHit     = "hit"     // served from the cache
+	resultMiss    = "miss"    // loaded from the database or waited for another caller's load
+	resultRefresh = "refresh" // found in the cache but refreshed early
+	resultBypass  = "bypass"  // loaded from the database because the cache could not be read
+)
+
+// Metrics records how Load is served, per key prefix. The hit ratio of a prefix is its hits over
+// all its requests. A nil *Metrics records nothing.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Cache loads by key prefix and result: hit, miss, refresh or bypass.",
+		}, []string{"prefix", "result"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_errors_total",
+			Help: "Failed cache operations by key prefix and operation.",
+		}, []string{"prefix", "op"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_load_duration_seconds",
+			Help:    "Time a cache load took, including the database on a miss, by key prefix and result.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"prefix", "result"}),
+	}
+	reg.MustRegister(m.requests, m.errors, m.duration)
+
+	return m
+}
+
+func (m *Metrics) observe(key string, result string, start time.Time) {
+	if m == nil {
+		return
+	}
+
+	prefix := keyPrefix(key)
+	m.requests.WithLabelValues(prefix, result).Inc()
+	m.duration.WithLabelValues(prefix, result).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) failed(key string, op string) {
+	if m == nil {
+		return
+	}
+
+	m.errors.WithLabelValues(keyPrefix(key), op).Inc()
+}
+
+// keyPrefix is the key up to and including its first colon, or up to its version for keys without
+// one. Keys are named prefix:id, so the label stays bounded.
+func keyPrefix(key string) string {
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i+1]
+	}
+	if i := strings.IndexByte(key, '@'); i >= 0 {
+		return key[:i]
+	}
+
+	return key
+}
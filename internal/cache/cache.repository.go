@@ -2,31 +2,39 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
+	"github.com/isd-sgcu/rpkm67-backend/config"
 	"github.com/redis/go-redis/v9"
 )
 
+// Repository methods run under the caller's context, bounded by the configured timeout
 type Repository interface {
-	SetValue(key string, value interface{}, ttl int) error
-	GetValue(key string, value interface{}) error
-	DeleteValue(key string) error
+	SetValue(ctx context.Context, key string, value interface{}, ttl int) error
+	SetValueNX(ctx context.Context, key string, value interface{}, ttl int) (bool, error)
+	GetValue(ctx context.Context, key string, value interface{}) error
+	DeleteValue(ctx context.Context, key string) error
+	DeleteByPrefix(ctx context.Context, prefix string) error
+	Increment(ctx context.Context, key string) (int64, error)
 }
 
+const defaultTimeout = 5 * time.Second
+
 type repositoryImpl struct {
-	client *redis.Client
+	client  *redis.Client
+	codecs  Codecs
+	timeout time.Duration
 }
 
-func NewRepository(client *redis.Client) Repository {
-	return &repositoryImpl{client: client}
+func NewRepository(client *redis.Client, codecs Codecs, conf *config.CacheConfig) Repository {
+	return &repositoryImpl{client: client, codecs: codecs, timeout: timeout(conf)}
 }
 
-func (r *repositoryImpl) SetValue(key string, value interface{}, ttl int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *repositoryImpl) SetValue(ctx context.Context, key string, value interface{}, ttl int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	v, err := json.Marshal(value)
+	v, err := r.codecs.Marshal(key, value)
 	if err != nil {
 		return err
 	}
@@ -34,8 +42,21 @@ func (r *repositoryImpl) SetValue(key string, value interface{}, ttl int) error
 	return r.client.Set(ctx, key, v, time.Duration(ttl)*time.Second).Err()
 }
 
-func (r *repositoryImpl) GetValue(key string, value interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// SetValueNX sets the value only if key does not exist, reporting whether it was set
+func (r *repositoryImpl) SetValueNX(ctx context.Context, key string, value interface{}, ttl int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	v, err := r.codecs.Marshal(key, value)
+	if err != nil {
+		return false, err
+	}
+
+	return r.client.SetNX(ctx, key, v, time.Duration(ttl)*time.Second).Result()
+}
+
+func (r *repositoryImpl) GetValue(ctx context.Context, key string, value interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	v, err := r.client.Get(ctx, key).Result()
@@ -43,12 +64,42 @@ func (r *repositoryImpl) GetValue(key string, value interface{}) error {
 		return err
 	}
 
-	return json.Unmarshal([]byte(v), value)
+	return r.codecs.Unmarshal(key, []byte(v), value)
 }
 
-func (r *repositoryImpl) DeleteValue(key string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *repositoryImpl) DeleteValue(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	return r.client.Del(ctx, key).Err()
 }
+
+func (r *repositoryImpl) DeleteByPrefix(ctx context.Context, prefix string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	iter := r.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := r.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
+
+func (r *repositoryImpl) Increment(ctx context.Context, key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.client.Incr(ctx, key).Result()
+}
+
+// timeout is how long a Redis call may take when the caller's context allows it
+func timeout(conf *config.CacheConfig) time.Duration {
+	if conf.Timeout <= 0 {
+		return defaultTimeout
+	}
+
+	return time.Duration(conf.Timeout) * time.Millisecond
+}
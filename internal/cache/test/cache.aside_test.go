@@ -0,0 +1,323 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	mock_cache "github.com/isd-sgcu/rpkm67-backend/mocks/cache"
+	selectionProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type AsideTest struct {
+	suite.Suite
+	ctx    context.Context
+	redis  *miniredis.Miniredis
+	client *redis.Client
+	conf   *config.CacheConfig
+	aside  cache.Aside
+}
+
+func TestAside(t *testing.T) {
+	suite.Run(t, new(AsideTest))
+}
+
+func (t *AsideTest) SetupTest() {
+	t.ctx = context.Background()
+	t.redis = miniredis.RunT(t.T())
+	t.client = redis.NewClient(&redis.Options{Addr: t.redis.Addr()})
+	t.conf = &config.CacheConfig{LockTTL: 1}
+	t.aside = cache.NewAside(cache.NewRepository(t.client, nil, t.conf), nil, t.conf, nil, zap.NewNop())
+}
+
+// load returns a loader that stores value and counts its calls
+func load(value string, target *string, calls *int) func() error {
+	return func() error {
+		*calls++
+		*target = value
+		return nil
+	}
+}
+
+func (t *AsideTest) TestLoadMissThenHit() {
+	calls := 0
+	var first, second string
+
+	t.NoError(t.aside.Load(t.ctx, "key", &first, 60, load("db", &first, &calls)))
+	t.NoError(t.aside.Load(t.ctx, "key", &second, 60, load("other", &second, &calls)))
+
+	t.Equal("db", first)
+	t.Equal("db", second)
+	t.Equal(1, calls)
+}
+
+func (t *AsideTest) TestInvalidate() {
+	calls := 0
+	var value string
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, load("old", &value, &calls)))
+
+	t.NoError(t.aside.Invalidate(t.ctx, "key"))
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, load("new", &value, &calls)))
+
+	t.Equal("new", value)
+	t.Equal(2, calls)
+}
+
+func (t *AsideTest) TestInvalidateDuringLoad() {
+	calls := 0
+	var value string
+
+	// a write commits and invalidates after the reader started loading the old value
+	err := t.aside.Load(t.ctx, "key", &value, 60, func() error {
+		value = "stale"
+		return t.aside.Invalidate(t.ctx, "key")
+	})
+	t.NoError(err)
+
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, load("fresh", &value, &calls)))
+
+	t.Equal("fresh", value)
+	t.Equal(1, calls)
+}
+
+func (t *AsideTest) TestInvalidatePrefix() {
+	calls := 0
+	var value string
+	t.NoError(t.aside.Load(t.ctx, "prefix:a", &value, 60, load("old", &value, &calls)))
+
+	t.NoError(t.aside.InvalidatePrefix(t.ctx, "prefix:"))
+	t.NoError(t.aside.Load(t.ctx, "prefix:a", &value, 60, load("new", &value, &calls)))
+
+	t.Equal("new", value)
+	t.Equal(2, calls)
+}
+
+func (t *AsideTest) TestLoadError() {
+	var value string
+	loadErr := errors.New("error")
+
+	err := t.aside.Load(t.ctx, "key", &value, 60, func() error { return loadErr })
+
+	t.ErrorIs(err, loadErr)
+	t.False(t.redis.Exists("key@0"))
+}
+
+func (t *AsideTest) TestLoadCacheDown() {
+	calls := 0
+	var value string
+	t.redis.Close()
+
+	err := t.aside.Load(t.ctx, "key", &value, 60, load("db", &value, &calls))
+
+	t.NoError(err)
+	t.Equal("db", value)
+	t.Equal(1, calls)
+}
+
+func (t *AsideTest) TestLoadConcurrentMissLoadsOnce() {
+	var calls int32
+	var wg sync.WaitGroup
+	values := make([]string, 20)
+
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := t.aside.Load(t.ctx, "key", &values[i], 60, func() error {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+				values[i] = "db"
+				return nil
+			})
+			t.NoError(err)
+		}(i)
+	}
+	wg.Wait()
+
+	t.Equal(int32(1), calls)
+	for _, value := range values {
+		t.Equal("db", value)
+	}
+}
+
+func (t *AsideTest) TestLoadWaitsForOtherInstance() {
+	other := cache.NewAside(cache.NewRepository(t.client, nil, t.conf), nil, t.conf, nil, zap.NewNop())
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	var first string
+	go func() {
+		defer close(done)
+		err := other.Load(t.ctx, "key", &first, 60, func() error {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			first = "db"
+			return nil
+		})
+		t.NoError(err)
+	}()
+	<-started
+
+	calls := 0
+	var second string
+	t.NoError(t.aside.Load(t.ctx, "key", &second, 60, load("other", &second, &calls)))
+	<-done
+
+	t.Equal("db", second)
+	t.Equal(0, calls)
+}
+
+func (t *AsideTest) TestEarlyRefresh() {
+	t.conf.EarlyRefreshBeta = 1e9
+	calls := 0
+	var value string
+	slowLoad := func(result string) func() error {
+		return func() error {
+			calls++
+			time.Sleep(5 * time.Millisecond)
+			value = result
+			return nil
+		}
+	}
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, slowLoad("old")))
+
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, slowLoad("new")))
+
+	t.Equal("new", value)
+	t.Equal(2, calls)
+}
+
+func (t *AsideTest) TestEarlyRefreshLockedReturnsCached() {
+	t.conf.EarlyRefreshBeta = 1e9
+	var value string
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, func() error {
+		time.Sleep(5 * time.Millisecond)
+		value = "old"
+		return nil
+	}))
+	t.NoError(t.redis.Set("lock:key@0", "true"))
+
+	calls := 0
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, load("new", &value, &calls)))
+
+	t.Equal("old", value)
+	t.Equal(0, calls)
+}
+
+func (t *AsideTest) TestLoadCancelled() {
+	ctx, cancel := context.WithCancel(t.ctx)
+	cancel()
+	calls := 0
+	var value string
+
+	err := t.aside.Load(ctx, "key", &value, 60, load("db", &value, &calls))
+
+	t.ErrorIs(err, context.Canceled)
+	t.Equal(0, calls)
+}
+
+func (t *AsideTest) TestLoadWaiterCancelledSharedLoadContinues() {
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	var first string
+	go func() {
+		defer close(done)
+		err := t.aside.Load(t.ctx, "key", &first, 60, func() error {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			first = "db"
+			return nil
+		})
+		t.NoError(err)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(t.ctx, 20*time.Millisecond)
+	defer cancel()
+	var second string
+	err := t.aside.Load(ctx, "key", &second, 60, func() error { return nil })
+	<-done
+
+	t.ErrorIs(err, context.DeadlineExceeded)
+	t.Equal("db", first)
+}
+
+func (t *AsideTest) TestLoadWithPrefixCodec() {
+	codecs := cache.Codecs{"count": cache.ProtoCodec{}}
+	aside := cache.NewAside(cache.NewRepository(t.client, codecs, t.conf), codecs, t.conf, nil, zap.NewNop())
+	calls := 0
+	loadCount := func(value *selectionProto.CountByBaanIdSelectionResponse) func() error {
+		return func() error {
+			calls++
+			value.BaanCounts = []*selectionProto.BaanCount{{BaanId: "baan1", Count: 3}}
+			return nil
+		}
+	}
+
+	first := &selectionProto.CountByBaanIdSelectionResponse{}
+	t.NoError(aside.Load(t.ctx, "count", first, 60, loadCount(first)))
+	second := &selectionProto.CountByBaanIdSelectionResponse{}
+	t.NoError(aside.Load(t.ctx, "count", second, 60, loadCount(second)))
+
+	t.Equal(1, calls)
+	t.Equal(int32(3), second.BaanCounts[0].Count)
+}
+
+func (t *AsideTest) TestEarlyRefreshErrorReturnsCached() {
+	t.conf.EarlyRefreshBeta = 1e9
+	var value string
+	t.NoError(t.aside.Load(t.ctx, "key", &value, 60, func() error {
+		time.Sleep(5 * time.Millisecond)
+		value = "old"
+		return nil
+	}))
+
+	value = ""
+	err := t.aside.Load(t.ctx, "key", &value, 60, func() error { return errors.New("error") })
+
+	t.NoError(err)
+	t.Equal("old", value)
+}
+
+func (t *AsideTest) TestInvalidateRetriesIncrement() {
+	repo := mock_cache.NewMockRepository(gomock.NewController(t.T()))
+	aside := cache.NewAside(repo, nil, t.conf, nil, zap.NewNop())
+
+	gomock.InOrder(
+		repo.EXPECT().Increment(gomock.Any(), "version:key").Return(int64(0), errors.New("error")),
+		repo.EXPECT().Increment(gomock.Any(), "version:key").Return(int64(1), nil),
+	)
+
+	t.NoError(aside.Invalidate(t.ctx, "key"))
+}
+
+func (t *AsideTest) TestInvalidateFailedIncrementDeletesEntry() {
+	repo := mock_cache.NewMockRepository(gomock.NewController(t.T()))
+	aside := cache.NewAside(repo, nil, t.conf, nil, zap.NewNop())
+
+	repo.EXPECT().Increment(gomock.Any(), "version:key").Return(int64(0), errors.New("error")).Times(2)
+	repo.EXPECT().GetValue(gomock.Any(), "version:key", gomock.Any()).SetArg(2, int64(3)).Return(nil)
+	repo.EXPECT().DeleteValue(gomock.Any(), "key@3").Return(nil)
+
+	t.NoError(aside.Invalidate(t.ctx, "key"))
+}
+
+func (t *AsideTest) TestInvalidateCacheDown() {
+	t.redis.Close()
+
+	err := t.aside.Invalidate(t.ctx, "a", "b")
+
+	t.ErrorContains(err, "failed to invalidate a")
+	t.ErrorContains(err, "failed to invalidate b")
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	selectionProto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
+	"github.com/isd-sgcu/rpkm67-model/model"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+)
+
+type CodecTest struct {
+	suite.Suite
+}
+
+func TestCodec(t *testing.T) {
+	suite.Run(t, new(CodecTest))
+}
+
+func newGroup(members int) *model.Group {
+	leaderId := uuid.New()
+	group := &model.Group{Base: model.Base{ID: uuid.New()}, LeaderID: &leaderId, Token: "token"}
+	for i := 0; i < members; i++ {
+		group.Members = append(group.Members, &model.User{
+			Base:      model.Base{ID: uuid.New()},
+			Email:     fmt.Sprintf("student%d@student.chula.ac.th", i),
+			Firstname: "Firstname",
+			Lastname:  "Lastname",
+			Faculty:   "21",
+			Year:      1,
+			PhotoUrl:  "https://storage.example.com/photos/" + uuid.NewString(),
+			GroupID:   &group.ID,
+		})
+	}
+
+	return group
+}
+
+func newCount(baans int) *selectionProto.CountByBaanIdSelectionResponse {
+	count := &selectionProto.CountByBaanIdSelectionResponse{}
+	for i := 0; i < baans; i++ {
+		count.BaanCounts = append(count.BaanCounts, &selectionProto.BaanCount{BaanId: fmt.Sprintf("baan%d", i), Count: int32(i)})
+	}
+
+	return count
+}
+
+func (t *CodecTest) TestCompressRoundTrip() {
+	for _, compression := range []cache.Compression{cache.Snappy, cache.Zstd} {
+		codec := cache.Compress(cache.JSONCodec{}, compression, 64)
+		group := newGroup(5)
+
+		data, err := codec.Marshal(group)
+		t.NoError(err)
+		plain, _ := cache.JSONCodec{}.Marshal(group)
+		t.Less(len(data), len(plain), compression)
+
+		decoded := &model.Group{}
+		t.NoError(codec.Unmarshal(data, decoded))
+		t.Equal(group.Members[4].Email, decoded.Members[4].Email)
+	}
+}
+
+func (t *CodecTest) TestCompressBelowThreshold() {
+	codec := cache.Compress(cache.JSONCodec{}, cache.Zstd, 1024)
+
+	data, err := codec.Marshal("small")
+
+	t.NoError(err)
+	t.Equal(`"small"`, string(data))
+}
+
+func (t *CodecTest) TestCompressReadsOtherFormats() {
+	zstdData, err := cache.Compress(cache.JSONCodec{}, cache.Zstd, 0).Marshal(newGroup(3))
+	t.NoError(err)
+	plainData, err := cache.JSONCodec{}.Marshal(newGroup(3))
+	t.NoError(err)
+	codec := cache.Compress(cache.JSONCodec{}, cache.Snappy, 0)
+
+	t.NoError(codec.Unmarshal(zstdData, &model.Group{}))
+	t.NoError(codec.Unmarshal(plainData, &model.Group{}))
+}
+
+func (t *CodecTest) TestProtoRoundTrip() {
+	count := newCount(3)
+
+	data, err := cache.ProtoCodec{}.Marshal(count)
+	t.NoError(err)
+	decoded := &selectionProto.CountByBaanIdSelectionResponse{}
+	t.NoError(cache.ProtoCodec{}.Unmarshal(data, decoded))
+
+	t.True(proto.Equal(count, decoded))
+}
+
+func (t *CodecTest) TestProtoRejectsOtherValues() {
+	_, err := cache.ProtoCodec{}.Marshal(newGroup(1))
+
+	t.Error(err)
+}
+
+func (t *CodecTest) TestCodecsLongestPrefix() {
+	codecs := cache.Codecs{
+		"group":          cache.ProtoCodec{},
+		"groupByToken:":  cache.Compress(cache.JSONCodec{}, cache.Snappy, 0),
+		"countByBaanId":  cache.ProtoCodec{},
+		"unrelatedGroup": cache.ProtoCodec{},
+	}
+
+	t.IsType(cache.JSONCodec{}, codecs.For("version:groupByToken:abc"))
+	t.IsType(cache.ProtoCodec{}, codecs.For("groupByUserId:abc"))
+	t.NotEqual(cache.ProtoCodec{}, codecs.For("groupByToken:abc"))
+}
+
+func (t *CodecTest) TestCodecsRawPassesThrough() {
+	codecs := cache.Codecs{"key": cache.ProtoCodec{}}
+
+	data, err := codecs.Marshal("key", cache.Raw("bytes"))
+	t.NoError(err)
+	var raw cache.Raw
+	t.NoError(codecs.Unmarshal("key", data, &raw))
+
+	t.Equal("bytes", string(raw))
+}
+
+func BenchmarkCodecs(b *testing.B) {
+	group := newGroup(30)
+	count := newCount(40)
+	benchmarks := []struct {
+		name  string
+		codec cache.Codec
+		value interface{}
+		new   func() interface{}
+	}{
+		{"group/json", cache.JSONCodec{}, group, func() interface{} { return &model.Group{} }},
+		{"group/json+snappy", cache.Compress(cache.JSONCodec{}, cache.Snappy, 0), group, func() interface{} { return &model.Group{} }},
+		{"group/json+zstd", cache.Compress(cache.JSONCodec{}, cache.Zstd, 0), group, func() interface{} { return &model.Group{} }},
+		{"count/json", cache.JSONCodec{}, count, func() interface{} { return &selectionProto.CountByBaanIdSelectionResponse{} }},
+		{"count/proto", cache.ProtoCodec{}, count, func() interface{} { return &selectionProto.CountByBaanIdSelectionResponse{} }},
+		{"count/proto+zstd", cache.Compress(cache.ProtoCodec{}, cache.Zstd, 0), count, func() interface{} { return &selectionProto.CountByBaanIdSelectionResponse{} }},
+	}
+
+	for _, bm := range benchmarks {
+		data, err := bm.codec.Marshal(bm.value)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(bm.name+"/marshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.codec.Marshal(bm.value); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes")
+		})
+
+		b.Run(bm.name+"/unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := bm.codec.Unmarshal(data, bm.new()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
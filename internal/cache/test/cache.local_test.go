@@ -0,0 +1,149 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type LocalTest struct {
+	suite.Suite
+	ctx    context.Context
+	redis  *miniredis.Miniredis
+	client *redis.Client
+	conf   *config.CacheConfig
+	repo   cache.Repository
+}
+
+func TestLocal(t *testing.T) {
+	suite.Run(t, new(LocalTest))
+}
+
+func (t *LocalTest) SetupTest() {
+	t.ctx = context.Background()
+	t.redis = miniredis.RunT(t.T())
+	t.client = redis.NewClient(&redis.Options{Addr: t.redis.Addr()})
+	t.conf = &config.CacheConfig{LocalSize: 2, LocalTTL: 60}
+	t.repo = cache.NewLocalRepository(cache.NewRepository(t.client, nil, t.conf), t.client, nil, t.conf, zap.NewNop())
+}
+
+func (t *LocalTest) newReplica() cache.Repository {
+	return cache.NewLocalRepository(cache.NewRepository(t.client, nil, t.conf), t.client, nil, t.conf, zap.NewNop())
+}
+
+func (t *LocalTest) TestGetValueServedLocally() {
+	t.NoError(t.redis.Set("key", `"redis"`))
+	var value string
+	t.NoError(t.repo.GetValue(t.ctx, "key", &value))
+
+	// the local copy is served without going to redis
+	t.NoError(t.redis.Set("key", `"changed"`))
+	t.NoError(t.repo.GetValue(t.ctx, "key", &value))
+
+	t.Equal("redis", value)
+}
+
+func (t *LocalTest) TestPerKeyTTL() {
+	t.NoError(t.repo.SetValue(t.ctx, "key", "local", 1))
+	t.NoError(t.redis.Set("key", `"changed"`))
+
+	time.Sleep(1100 * time.Millisecond)
+	var value string
+	t.NoError(t.repo.GetValue(t.ctx, "key", &value))
+
+	t.Equal("changed", value)
+}
+
+func (t *LocalTest) TestEvictsLeastRecentlyUsed() {
+	var value string
+	t.NoError(t.repo.SetValue(t.ctx, "a", "a", 60))
+	t.NoError(t.repo.SetValue(t.ctx, "b", "b", 60))
+	t.NoError(t.repo.GetValue(t.ctx, "a", &value))
+	t.NoError(t.repo.SetValue(t.ctx, "c", "c", 60))
+
+	t.NoError(t.redis.Set("a", `"a2"`))
+	t.NoError(t.redis.Set("b", `"b2"`))
+
+	t.NoError(t.repo.GetValue(t.ctx, "a", &value))
+	t.Equal("a", value)
+	t.NoError(t.repo.GetValue(t.ctx, "b", &value))
+	t.Equal("b2", value)
+}
+
+func (t *LocalTest) TestWriteInvalidatesOtherReplica() {
+	other := t.newReplica()
+	var value string
+	t.NoError(t.repo.SetValue(t.ctx, "key", "old", 60))
+	t.NoError(other.GetValue(t.ctx, "key", &value))
+
+	t.NoError(t.repo.SetValue(t.ctx, "key", "new", 60))
+
+	t.Eventually(func() bool {
+		return other.GetValue(t.ctx, "key", &value) == nil && value == "new"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (t *LocalTest) TestIncrementInvalidatesOtherReplica() {
+	other := t.newReplica()
+	var version int64
+	t.NoError(t.repo.SetValue(t.ctx, "version", 1, 0))
+	t.NoError(other.GetValue(t.ctx, "version", &version))
+
+	_, err := t.repo.Increment(t.ctx, "version")
+	t.NoError(err)
+
+	t.Eventually(func() bool {
+		return other.GetValue(t.ctx, "version", &version) == nil && version == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (t *LocalTest) TestDeleteByPrefixInvalidatesOtherReplica() {
+	other := t.newReplica()
+	var value string
+	t.NoError(t.repo.SetValue(t.ctx, "prefix:a", "a", 60))
+	t.NoError(other.GetValue(t.ctx, "prefix:a", &value))
+
+	t.NoError(t.repo.DeleteByPrefix(t.ctx, "prefix:"))
+
+	t.Eventually(func() bool {
+		return other.GetValue(t.ctx, "prefix:a", &value) != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (t *LocalTest) TestLoadNeverInvalidatedServedLocally() {
+	// room for the version, the entry and the refill lock
+	t.conf.LocalSize = 3
+	aside := cache.NewAside(t.repo, nil, t.conf, nil, zap.NewNop())
+	calls := 0
+	var value string
+	t.NoError(aside.Load(t.ctx, "key", &value, 60, load("db", &value, &calls)))
+
+	commands := t.redis.CommandCount()
+	t.NoError(aside.Load(t.ctx, "key", &value, 60, load("other", &value, &calls)))
+
+	t.Equal(commands, t.redis.CommandCount())
+	t.Equal("db", value)
+	t.Equal(1, calls)
+}
+
+func (t *LocalTest) TestVersionCreatedInvalidatesOtherReplica() {
+	other := cache.NewAside(t.newReplica(), nil, t.conf, nil, zap.NewNop())
+	aside := cache.NewAside(t.repo, nil, t.conf, nil, zap.NewNop())
+	calls := 0
+	var value string
+	t.NoError(aside.Load(t.ctx, "key", &value, 60, load("old", &value, &calls)))
+	t.NoError(other.Load(t.ctx, "key", &value, 60, load("old", &value, &calls)))
+
+	t.NoError(aside.Invalidate(t.ctx, "key"))
+
+	t.Eventually(func() bool {
+		return other.Load(t.ctx, "key", &value, 60, load("new", &value, &calls)) == nil && value == "new"
+	}, time.Second, 10*time.Millisecond)
+}
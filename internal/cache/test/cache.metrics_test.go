@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type MetricsTest struct {
+	suite.Suite
+	ctx      context.Context
+	redis    *miniredis.Miniredis
+	registry *prometheus.Registry
+	aside    cache.Aside
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsTest))
+}
+
+func (t *MetricsTest) SetupTest() {
+	t.ctx = context.Background()
+	t.redis = miniredis.RunT(t.T())
+	client := redis.NewClient(&redis.Options{Addr: t.redis.Addr()})
+	conf := &config.CacheConfig{LockTTL: 1}
+	t.registry = prometheus.NewRegistry()
+	metrics := cache.NewMetrics(t.registry)
+	t.aside = cache.NewAside(cache.NewRepository(client, nil, conf), nil, conf, metrics, zap.NewNop())
+}
+
+// requests counts the loads of prefix with result
+func (t *MetricsTest) requests(prefix string, result string) float64 {
+	families, err := t.registry.Gather()
+	t.NoError(err)
+
+	for _, family := range families {
+		if family.GetName() != "cache_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["prefix"] == prefix && labels["result"] == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
+
+func (t *MetricsTest) TestHitsAndMissesPerPrefix() {
+	calls := 0
+	var value string
+
+	t.NoError(t.aside.Load(t.ctx, "groupByUserId:a", &value, 60, load("db", &value, &calls)))
+	t.NoError(t.aside.Load(t.ctx, "groupByUserId:a", &value, 60, load("db", &value, &calls)))
+	t.NoError(t.aside.Load(t.ctx, "groupByUserId:b", &value, 60, load("db", &value, &calls)))
+	t.NoError(t.aside.Load(t.ctx, "countByBaanId", &value, 60, load("db", &value, &calls)))
+
+	t.Equal(float64(1), t.requests("groupByUserId:", "hit"))
+	t.Equal(float64(2), t.requests("groupByUserId:", "miss"))
+	t.Equal(float64(1), t.requests("countByBaanId", "miss"))
+	// the ids stay out of the labels, three series in each of the two families
+	t.Equal(6, testutil.CollectAndCount(t.registry, "cache_requests_total", "cache_load_duration_seconds"))
+}
+
+func (t *MetricsTest) TestCacheDownCountsErrors() {
+	calls := 0
+	var value string
+	t.redis.Close()
+
+	t.NoError(t.aside.Load(t.ctx, "groupByToken:a", &value, 60, load("db", &value, &calls)))
+
+	t.Equal(float64(1), t.requests("groupByToken:", "bypass"))
+	t.Equal(1, testutil.CollectAndCount(t.registry, "cache_errors_total"))
+}
@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"time"
+
+	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
+)
+
+type MoveUserAdminRequest struct {
+	StaffId string `json:"staff_id"`
+	UserId  string `json:"user_id"`
+	GroupId string `json:"group_id"`
+}
+
+type MoveUserAdminResponse struct {
+	Group *proto.Group `json:"group"`
+}
+
+type MergeGroupsAdminRequest struct {
+	StaffId       string `json:"staff_id"`
+	SourceGroupId string `json:"source_group_id"` // deleted after its members move
+	TargetGroupId string `json:"target_group_id"` // keeps its leader and token
+}
+
+type MergeGroupsAdminResponse struct {
+	Group *proto.Group `json:"group"`
+}
+
+type SplitGroupAdminRequest struct {
+	StaffId string   `json:"staff_id"`
+	GroupId string   `json:"group_id"`
+	UserIds []string `json:"user_ids"` // moved to a new group led by the first user
+}
+
+type SplitGroupAdminResponse struct {
+	Group    *proto.Group `json:"group"`
+	NewGroup *proto.Group `json:"new_group"`
+}
+
+type UpdateConfirmAdminRequest struct {
+	StaffId     string `json:"staff_id"`
+	GroupId     string `json:"group_id"`
+	IsConfirmed bool   `json:"is_confirmed"`
+}
+
+type UpdateConfirmAdminResponse struct {
+	Group *proto.Group `json:"group"`
+}
+
+type DeleteOrphanGroupsAdminRequest struct {
+	StaffId string `json:"staff_id"`
+}
+
+type DeleteOrphanGroupsAdminResponse struct {
+	GroupIds []string `json:"group_ids"`
+}
+
+type FindEventsAdminRequest struct {
+	StaffId  string `json:"staff_id"`
+	GroupId  string `json:"group_id"` // exactly one of group_id and user_id
+	UserId   string `json:"user_id"`
+	Page     int    `json:"page"` // starts at 1
+	PageSize int    `json:"page_size"`
+}
+
+type FindEventsAdminResponse struct {
+	Events   []*GroupEvent `json:"events"` // newest first
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+type SetWindowOverrideAdminRequest struct {
+	StaffId  string `json:"staff_id"`
+	Override bool   `json:"override"`
+}
+
+type SetWindowOverrideAdminResponse struct {
+	Override     bool       `json:"override"`
+	OverriddenBy string     `json:"overridden_by"`
+	OverriddenAt time.Time  `json:"overridden_at"`
+	LockedAt     *time.Time `json:"locked_at"` // nil until the groups are locked
+}
+
+type AllocateAdminRequest struct {
+	StaffId string `json:"staff_id"`
+	Seed    int64  `json:"seed"` // 0 picks one from the current time, only for a dry run
+	DryRun  bool   `json:"dry_run"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+type AllocateRequest struct {
+	Seed   int64 `json:"seed"`
+	DryRun bool  `json:"dry_run"`
+}
+
+type AllocateResponse struct {
+	Seed             int64 `json:"seed"`
+	TotalGroups      int   `json:"total_groups"`
+	AssignedGroups   int   `json:"assigned_groups"`
+	UnassignedGroups int   `json:"unassigned_groups"`
+	ChoiceCounts     []int `json:"choice_counts"` // ChoiceCounts[i] is the number of groups that got their (i+1)th choice
+}
@@ -0,0 +1,20 @@
+package dto
+
+type Baan struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"` // number of members, not groups
+	Size     string `json:"size"`     // S, M, L, XL or XXL
+	IsOpen   bool   `json:"is_open"`
+}
+
+type BaanCount struct {
+	Baan    *Baan `json:"baan"`
+	Members int   `json:"members"` // members of the groups ranking the baan first, comparable with its capacity
+}
+
+type CountCapacityByBaanIdRequest struct{}
+
+type CountCapacityByBaanIdResponse struct {
+	BaanCounts []*BaanCount `json:"baan_counts"`
+}
@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"time"
+
+	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
+)
+
+type RotateTokenGroupRequest struct {
+	LeaderId  string     `json:"leader_id"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil means the new token never expires
+	MaxUses   int        `json:"max_uses"`   // 0 means unlimited
+}
+
+type RotateTokenGroupResponse struct {
+	Group     *proto.Group `json:"group"`
+	ExpiresAt *time.Time   `json:"expires_at"`
+	MaxUses   int          `json:"max_uses"`
+}
+
+type TransferLeadershipGroupRequest struct {
+	LeaderId    string `json:"leader_id"`
+	NewLeaderId string `json:"new_leader_id"`
+}
+
+type TransferLeadershipGroupResponse struct {
+	Group *proto.Group `json:"group"`
+}
+
+type JoinRequest struct {
+	Id        string          `json:"id"`
+	GroupId   string          `json:"group_id"`
+	User      *proto.UserInfo `json:"user"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt *time.Time      `json:"expires_at"`
+}
+
+type ListJoinRequestsGroupRequest struct {
+	LeaderId string `json:"leader_id"`
+}
+
+type ListJoinRequestsGroupResponse struct {
+	JoinRequests []*JoinRequest `json:"join_requests"`
+}
+
+type AcceptJoinRequestGroupRequest struct {
+	LeaderId  string `json:"leader_id"`
+	RequestId string `json:"request_id"`
+}
+
+type AcceptJoinRequestGroupResponse struct {
+	Group *proto.Group `json:"group"`
+}
+
+type RejectJoinRequestGroupRequest struct {
+	LeaderId  string `json:"leader_id"`
+	RequestId string `json:"request_id"`
+}
+
+type RejectJoinRequestGroupResponse struct {
+	Success bool `json:"success"`
+}
+
+type GroupEvent struct {
+	Id             uint64    `json:"id"`
+	GroupId        string    `json:"group_id"`
+	Type           string    `json:"type"`
+	UserId         string    `json:"user_id"`
+	ActorId        string    `json:"actor_id"`
+	RelatedGroupId string    `json:"related_group_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DisbandGroupRequest struct {
+	LeaderId string `json:"leader_id"`
+}
+
+type DisbandGroupResponse struct {
+	Group *proto.Group `json:"group"`
+}
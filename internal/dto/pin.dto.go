@@ -1,5 +1,6 @@
 package dto
 
 type Pin struct {
-	Code string `json:"code"`
+	Code   string `json:"code"`
+	Secret string `json:"secret,omitempty"` // rotating pins derive the code from it
 }
@@ -0,0 +1,12 @@
+package dto
+
+import proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
+
+type ReplaceAllSelectionRequest struct {
+	GroupId string   `json:"group_id"`
+	BaanIds []string `json:"baan_ids"` // ranked from 1st to 5th choice
+}
+
+type ReplaceAllSelectionResponse struct {
+	Selections []*proto.Selection `json:"selections"`
+}
@@ -0,0 +1,94 @@
+package group
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-model/model"
+	"gorm.io/gorm"
+)
+
+// TokenPolicy limits how long and how many times the current invite token of a group can be used.
+// A group without a policy has a token that never expires.
+type TokenPolicy struct {
+	GroupID   uuid.UUID  `json:"group_id" gorm:"type:uuid;primaryKey"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses"` // 0 means unlimited
+	Uses      int        `json:"uses"`
+}
+
+// Membership records when a user joined their current group, used to pick the next leader
+type Membership struct {
+	UserID   uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	GroupID  uuid.UUID `json:"group_id" gorm:"type:uuid;index"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// JoinRequest is a pending request to join a group that needs the leader's approval
+type JoinRequest struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primaryKey"`
+	GroupID   uuid.UUID   `json:"group_id" gorm:"type:uuid;index"`
+	UserID    uuid.UUID   `json:"user_id" gorm:"type:uuid;index"`
+	User      *model.User `json:"user"`
+	CreatedAt time.Time   `json:"created_at"`
+	ExpiresAt *time.Time  `json:"expires_at"` // nil means the request never expires
+}
+
+func (m *JoinRequest) BeforeCreate(_ *gorm.DB) error {
+	m.ID = uuid.New()
+	return nil
+}
+
+func (m *JoinRequest) isExpired() bool {
+	return m.ExpiresAt != nil && !time.Now().Before(*m.ExpiresAt)
+}
+
+// GroupEvent is an append-only record of a change to a group, kept for staff support
+type GroupEvent struct {
+	ID             uint64                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	GroupID        uuid.UUID               `json:"group_id" gorm:"type:uuid;index"`
+	Type           constant.GroupEventType `json:"type"`
+	UserID         *uuid.UUID              `json:"user_id" gorm:"type:uuid;index"`    // the user the event is about
+	ActorID        *uuid.UUID              `json:"actor_id" gorm:"type:uuid;index"`   // who made the change, nil for the system
+	RelatedGroupID *uuid.UUID              `json:"related_group_id" gorm:"type:uuid"` // the other group of a move
+	CreatedAt      time.Time               `json:"created_at"`
+}
+
+// WindowState is the single row shared by every replica about the selection window: whether staff
+// have overridden it and when the groups were locked after it closed
+type WindowState struct {
+	ID           int        `json:"id" gorm:"primaryKey"`
+	Override     bool       `json:"override"`
+	OverriddenBy *uuid.UUID `json:"overridden_by" gorm:"type:uuid"` // the staff who last changed the override
+	OverriddenAt *time.Time `json:"overridden_at"`
+	LockedAt     *time.Time `json:"locked_at"` // nil until LockAll has run
+}
+
+// ConfirmSnapshot freezes a group as it was when confirmed, so allocation is reproducible after the
+// group changes. Snapshots are never edited, unconfirming only marks the current one superseded.
+type ConfirmSnapshot struct {
+	ID           uuid.UUID            `json:"id" gorm:"type:uuid;primaryKey"`
+	GroupID      uuid.UUID            `json:"group_id" gorm:"type:uuid;index"`
+	LeaderID     uuid.UUID            `json:"leader_id" gorm:"type:uuid"`
+	Members      []*SnapshotMember    `json:"members" gorm:"foreignKey:SnapshotID"`
+	Selections   []*SnapshotSelection `json:"selections" gorm:"foreignKey:SnapshotID"` // ordered by rank
+	ConfirmedAt  time.Time            `json:"confirmed_at"`
+	SupersededAt *time.Time           `json:"superseded_at" gorm:"index"` // nil for the current snapshot of the group
+}
+
+func (m *ConfirmSnapshot) BeforeCreate(_ *gorm.DB) error {
+	m.ID = uuid.New()
+	return nil
+}
+
+type SnapshotMember struct {
+	SnapshotID uuid.UUID `json:"snapshot_id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+}
+
+type SnapshotSelection struct {
+	SnapshotID uuid.UUID `json:"snapshot_id" gorm:"type:uuid;primaryKey"`
+	Order      int       `json:"order" gorm:"type:smallint;primaryKey"`
+	Baan       string    `json:"baan"`
+}
@@ -2,21 +2,61 @@ package group
 
 import (
 	"errors"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	WithTransaction(txFunc func(*gorm.DB) error) error
 	FindOne(id string, group *model.Group) error
+	FindOneForUpdateTX(tx *gorm.DB, id string, group *model.Group) error
 	FindByToken(token string, group *model.Group) error
-	UpdateConfirm(id string, group *model.Group) error
+	FindByTokenForUpdateTX(tx *gorm.DB, token string, group *model.Group) error
+	UpdateConfirmTX(tx *gorm.DB, id string, group *model.Group) error
+	UpdateTokenTX(tx *gorm.DB, id string, token string) error
+	UpdateLeaderTX(tx *gorm.DB, id string, leaderId *uuid.UUID) error
+	FindTokenPolicy(groupId string, policy *TokenPolicy) error
+	FindTokenPolicyTX(tx *gorm.DB, groupId string, policy *TokenPolicy) error
+	SaveTokenPolicyTX(tx *gorm.DB, policy *TokenPolicy) error
+	IncrementTokenUsesTX(tx *gorm.DB, groupId string) error
+	SaveMembershipTX(tx *gorm.DB, membership *Membership) error
+	FindMembershipsTX(tx *gorm.DB, groupId string, memberships *[]Membership) error
+	CreateJoinRequestTX(tx *gorm.DB, joinRequest *JoinRequest) error
+	FindJoinRequest(id string, joinRequest *JoinRequest) error
+	FindJoinRequestsByGroupId(groupId string, joinRequests *[]JoinRequest) error
+	DeleteJoinRequestTX(tx *gorm.DB, id string) error
+	DeleteJoinRequestsByUserIdTX(tx *gorm.DB, userId string) error
+	DeleteExpiredJoinRequests(groupId string) error
+	CreateEventsTX(tx *gorm.DB, events []*GroupEvent) error
+	FindEventsByGroupId(groupId string, offset int, limit int, events *[]GroupEvent, total *int64) error
+	FindEventsByUserId(userId string, offset int, limit int, events *[]GroupEvent, total *int64) error
+	ConfirmAllTX(tx *gorm.DB) ([]uuid.UUID, error)
+	FindWindowState(state *WindowState) error
+	FindWindowStateForUpdateTX(tx *gorm.DB, state *WindowState) error
+	SaveWindowStateTX(tx *gorm.DB, state *WindowState) error
+	FindOrphansForUpdateTX(tx *gorm.DB, groups *[]model.Group) error
+	CreateSnapshotTX(tx *gorm.DB, groupId string) error
+	SupersedeSnapshotTX(tx *gorm.DB, groupId string) error
 	CreateTX(tx *gorm.DB, group *model.Group) error
 	DeleteGroupTX(tx *gorm.DB, groupId *uuid.UUID) error
 }
 
+// SelectionRepository is the part of selection.Repository the group service needs,
+// declared here because the selection package already depends on this one
+type SelectionRepository interface {
+	FindByGroupIdTX(tx *gorm.DB, groupId string, selections *[]model.Selection) error
+	ReplaceByGroupIdTX(tx *gorm.DB, groupId string, selections []*model.Selection) error
+	DeleteByGroupIdTX(tx *gorm.DB, groupId string) error
+}
+
+const windowStateId = 1
+
 type repositoryImpl struct {
 	Db *gorm.DB
 }
@@ -28,37 +68,32 @@ func NewRepository(db *gorm.DB) Repository {
 }
 
 func (r *repositoryImpl) WithTransaction(txFunc func(*gorm.DB) error) error {
-	tx := r.Db.Begin()
-	if tx.Error != nil {
-		return tx.Error
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := txFunc(tx); err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	return tx.Commit().Error
+	return utils.WithTransaction(r.Db, txFunc)
 }
 
 func (r *repositoryImpl) FindOne(id string, group *model.Group) error {
 	return r.Db.Preload("Members").First(&group, "id = ?", id).Error
 }
 
+// FindOneForUpdateTX locks the group row until tx ends, so membership checks made after it hold until commit
+func (r *repositoryImpl) FindOneForUpdateTX(tx *gorm.DB, id string, group *model.Group) error {
+	return tx.Clauses(lockGroup()).Preload("Members").First(&group, "id = ?", id).Error
+}
+
 func (r *repositoryImpl) FindByToken(token string, group *model.Group) error {
 	return r.Db.Preload("Members").
 		Joins("JOIN users ON users.id = groups.leader_id").
 		First(&group, "token = ?", token).Error
 }
 
-func (r *repositoryImpl) UpdateConfirm(id string, group *model.Group) error {
-	result := r.Db.Model(&model.Group{}).Where("id = ?", id).Update("is_confirmed", group.IsConfirmed)
+func (r *repositoryImpl) FindByTokenForUpdateTX(tx *gorm.DB, token string, group *model.Group) error {
+	return tx.Clauses(lockGroup()).Preload("Members").
+		Joins("JOIN users ON users.id = groups.leader_id").
+		First(&group, "token = ?", token).Error
+}
+
+func (r *repositoryImpl) UpdateConfirmTX(tx *gorm.DB, id string, group *model.Group) error {
+	result := tx.Model(&model.Group{}).Where("id = ?", id).Update("is_confirmed", group.IsConfirmed)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -69,11 +104,216 @@ func (r *repositoryImpl) UpdateConfirm(id string, group *model.Group) error {
 	return nil
 }
 
+func (r *repositoryImpl) UpdateTokenTX(tx *gorm.DB, id string, token string) error {
+	result := tx.Model(&model.Group{}).Where("id = ?", id).Update("token", token)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("no group found with the given id")
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) UpdateLeaderTX(tx *gorm.DB, id string, leaderId *uuid.UUID) error {
+	result := tx.Model(&model.Group{}).Where("id = ?", id).Update("leader_id", leaderId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("no group found with the given id")
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) FindTokenPolicy(groupId string, policy *TokenPolicy) error {
+	return r.Db.First(&policy, "group_id = ?", groupId).Error
+}
+
+func (r *repositoryImpl) FindTokenPolicyTX(tx *gorm.DB, groupId string, policy *TokenPolicy) error {
+	return tx.First(&policy, "group_id = ?", groupId).Error
+}
+
+// SaveTokenPolicyTX replaces the policy of the group, resetting its use count
+func (r *repositoryImpl) SaveTokenPolicyTX(tx *gorm.DB, policy *TokenPolicy) error {
+	return tx.Save(&policy).Error
+}
+
+func (r *repositoryImpl) IncrementTokenUsesTX(tx *gorm.DB, groupId string) error {
+	return tx.Model(&TokenPolicy{}).Where("group_id = ?", groupId).Update("uses", gorm.Expr("uses + 1")).Error
+}
+
+// SaveMembershipTX replaces the user's membership, so it always points at their current group
+func (r *repositoryImpl) SaveMembershipTX(tx *gorm.DB, membership *Membership) error {
+	return tx.Save(&membership).Error
+}
+
+func (r *repositoryImpl) FindMembershipsTX(tx *gorm.DB, groupId string, memberships *[]Membership) error {
+	return tx.Find(&memberships, "group_id = ?", groupId).Error
+}
+
+func (r *repositoryImpl) CreateJoinRequestTX(tx *gorm.DB, joinRequest *JoinRequest) error {
+	return tx.Create(&joinRequest).Error
+}
+
+func (r *repositoryImpl) FindJoinRequest(id string, joinRequest *JoinRequest) error {
+	return r.Db.First(&joinRequest, "id = ?", id).Error
+}
+
+func (r *repositoryImpl) FindJoinRequestsByGroupId(groupId string, joinRequests *[]JoinRequest) error {
+	return r.Db.Preload("User").Order("created_at").
+		Find(&joinRequests, "group_id = ?", groupId).Error
+}
+
+func (r *repositoryImpl) DeleteJoinRequestTX(tx *gorm.DB, id string) error {
+	result := tx.Delete(&JoinRequest{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("no join request found with the given id")
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) DeleteJoinRequestsByUserIdTX(tx *gorm.DB, userId string) error {
+	return tx.Delete(&JoinRequest{}, "user_id = ?", userId).Error
+}
+
+func (r *repositoryImpl) DeleteExpiredJoinRequests(groupId string) error {
+	return r.Db.Delete(&JoinRequest{}, "group_id = ? AND expires_at <= ?", groupId, time.Now()).Error
+}
+
+func (r *repositoryImpl) CreateEventsTX(tx *gorm.DB, events []*GroupEvent) error {
+	return tx.CreateInBatches(&events, 500).Error
+}
+
+func (r *repositoryImpl) FindEventsByGroupId(groupId string, offset int, limit int, events *[]GroupEvent, total *int64) error {
+	return r.findEvents(func(db *gorm.DB) *gorm.DB {
+		return db.Where("group_id = ?", groupId)
+	}, offset, limit, events, total)
+}
+
+// FindEventsByUserId finds the events about the user and the changes they made to other users
+func (r *repositoryImpl) FindEventsByUserId(userId string, offset int, limit int, events *[]GroupEvent, total *int64) error {
+	return r.findEvents(func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? OR actor_id = ?", userId, userId)
+	}, offset, limit, events, total)
+}
+
+func (r *repositoryImpl) findEvents(filter func(*gorm.DB) *gorm.DB, offset int, limit int, events *[]GroupEvent, total *int64) error {
+	if err := r.Db.Model(&GroupEvent{}).Scopes(filter).Count(total).Error; err != nil {
+		return err
+	}
+
+	return r.Db.Scopes(filter).Order("id DESC").Offset(offset).Limit(limit).Find(&events).Error
+}
+
+// ConfirmAllTX confirms every unconfirmed group and returns their ids
+func (r *repositoryImpl) ConfirmAllTX(tx *gorm.DB) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	if err := tx.Model(&model.Group{}).Clauses(lockGroup()).Where("is_confirmed = ?", false).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	if err := tx.Model(&model.Group{}).Where("id IN ?", ids).Update("is_confirmed", true).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// FindWindowState finds the window state, which is the zero state until it is first saved
+func (r *repositoryImpl) FindWindowState(state *WindowState) error {
+	return r.Db.FirstOrInit(state, WindowState{ID: windowStateId}).Error
+}
+
+// FindWindowStateForUpdateTX creates the window state if it is missing and locks it, so only one
+// replica changes it at a time
+func (r *repositoryImpl) FindWindowStateForUpdateTX(tx *gorm.DB, state *WindowState) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&WindowState{ID: windowStateId}).Error; err != nil {
+		return err
+	}
+
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(state, windowStateId).Error
+}
+
+func (r *repositoryImpl) SaveWindowStateTX(tx *gorm.DB, state *WindowState) error {
+	state.ID = windowStateId
+	return tx.Save(state).Error
+}
+
+// FindOrphansForUpdateTX locks the groups that no user belongs to
+func (r *repositoryImpl) FindOrphansForUpdateTX(tx *gorm.DB, groups *[]model.Group) error {
+	return tx.Clauses(lockGroup()).
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.group_id = groups.id)").
+		Find(&groups).Error
+}
+
+// CreateSnapshotTX records the group's current leader, members and selections as its confirmed snapshot,
+// superseding the previous one
+func (r *repositoryImpl) CreateSnapshotTX(tx *gorm.DB, groupId string) error {
+	group := &model.Group{}
+	if err := tx.Preload("Members").Preload("Selections").First(&group, "id = ?", groupId).Error; err != nil {
+		return err
+	}
+	if group.LeaderID == nil {
+		return errors.New("group has no leader")
+	}
+
+	if err := r.SupersedeSnapshotTX(tx, groupId); err != nil {
+		return err
+	}
+
+	snapshot := &ConfirmSnapshot{
+		GroupID:     group.ID,
+		LeaderID:    *group.LeaderID,
+		Members:     make([]*SnapshotMember, len(group.Members)),
+		Selections:  make([]*SnapshotSelection, len(group.Selections)),
+		ConfirmedAt: time.Now(),
+	}
+	for i, member := range group.Members {
+		snapshot.Members[i] = &SnapshotMember{UserID: member.ID}
+	}
+	for i, selection := range group.Selections {
+		snapshot.Selections[i] = &SnapshotSelection{Order: selection.Order, Baan: selection.Baan}
+	}
+	sort.Slice(snapshot.Selections, func(i, j int) bool {
+		return snapshot.Selections[i].Order < snapshot.Selections[j].Order
+	})
+
+	return tx.Create(&snapshot).Error
+}
+
+func (r *repositoryImpl) SupersedeSnapshotTX(tx *gorm.DB, groupId string) error {
+	return tx.Model(&ConfirmSnapshot{}).Where("group_id = ? AND superseded_at IS NULL", groupId).
+		Update("superseded_at", time.Now()).Error
+}
+
 func (r *repositoryImpl) CreateTX(tx *gorm.DB, group *model.Group) error {
 	return tx.Create(&group).Error
 }
 
 func (r *repositoryImpl) DeleteGroupTX(tx *gorm.DB, groupId *uuid.UUID) error {
+	if err := r.SupersedeSnapshotTX(tx, groupId.String()); err != nil {
+		return err
+	}
+	if err := tx.Delete(&TokenPolicy{}, "group_id = ?", groupId).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&JoinRequest{}, "group_id = ?", groupId).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&model.Selection{}, "group_id = ?", groupId).Error; err != nil {
+		return err
+	}
+
 	result := tx.Delete(&model.Group{}, "id = ?", groupId)
 	if result.Error != nil {
 		return result.Error
@@ -84,3 +324,8 @@ func (r *repositoryImpl) DeleteGroupTX(tx *gorm.DB, groupId *uuid.UUID) error {
 
 	return nil
 }
+
+// lockGroup only locks the groups table, leaving joined users rows untouched
+func lockGroup() clause.Locking {
+	return clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "groups"}}
+}
@@ -0,0 +1,39 @@
+package group
+
+import (
+	"context"
+
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	"google.golang.org/grpc"
+)
+
+// JSONServer is the part of Service whose messages are not in rpkm67-go-proto yet. It is served
+// by the GroupJSONService with dto messages encoded by utils.JSONCodec.
+type JSONServer interface {
+	ListJoinRequests(ctx context.Context, in *dto.ListJoinRequestsGroupRequest) (*dto.ListJoinRequestsGroupResponse, error)
+	AcceptJoinRequest(ctx context.Context, in *dto.AcceptJoinRequestGroupRequest) (*dto.AcceptJoinRequestGroupResponse, error)
+	RejectJoinRequest(ctx context.Context, in *dto.RejectJoinRequestGroupRequest) (*dto.RejectJoinRequestGroupResponse, error)
+	TransferLeadership(ctx context.Context, in *dto.TransferLeadershipGroupRequest) (*dto.TransferLeadershipGroupResponse, error)
+	RotateToken(ctx context.Context, in *dto.RotateTokenGroupRequest) (*dto.RotateTokenGroupResponse, error)
+	Disband(ctx context.Context, in *dto.DisbandGroupRequest) (*dto.DisbandGroupResponse, error)
+}
+
+func RegisterGroupJSONServiceServer(s grpc.ServiceRegistrar, srv JSONServer) {
+	s.RegisterService(&groupJSONServiceDesc, srv)
+}
+
+var groupJSONServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rpkm67.backend.group.v1.GroupJSONService",
+	HandlerType: (*JSONServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ListJoinRequests", Handler: utils.UnaryHandler(constant.GroupJSONService_ListJoinRequests_FullMethodName, JSONServer.ListJoinRequests)},
+		{MethodName: "AcceptJoinRequest", Handler: utils.UnaryHandler(constant.GroupJSONService_AcceptJoinRequest_FullMethodName, JSONServer.AcceptJoinRequest)},
+		{MethodName: "RejectJoinRequest", Handler: utils.UnaryHandler(constant.GroupJSONService_RejectJoinRequest_FullMethodName, JSONServer.RejectJoinRequest)},
+		{MethodName: "TransferLeadership", Handler: utils.UnaryHandler(constant.GroupJSONService_TransferLeadership_FullMethodName, JSONServer.TransferLeadership)},
+		{MethodName: "RotateToken", Handler: utils.UnaryHandler(constant.GroupJSONService_RotateToken_FullMethodName, JSONServer.RotateToken)},
+		{MethodName: "Disband", Handler: utils.UnaryHandler(constant.GroupJSONService_Disband_FullMethodName, JSONServer.Disband)},
+	},
+	Metadata: "internal/group/group.rpc.go",
+}
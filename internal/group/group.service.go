@@ -2,14 +2,22 @@ package group
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
 	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
 	"github.com/isd-sgcu/rpkm67-backend/internal/user"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	"github.com/isd-sgcu/rpkm67-backend/internal/window"
 	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
 	"github.com/isd-sgcu/rpkm67-model/model"
+	modelUtils "github.com/isd-sgcu/rpkm67-model/utils"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -18,29 +26,45 @@ import (
 
 type Service interface {
 	proto.GroupServiceServer
+	RotateToken(ctx context.Context, in *dto.RotateTokenGroupRequest) (*dto.RotateTokenGroupResponse, error)
+	TransferLeadership(ctx context.Context, in *dto.TransferLeadershipGroupRequest) (*dto.TransferLeadershipGroupResponse, error)
+	ListJoinRequests(ctx context.Context, in *dto.ListJoinRequestsGroupRequest) (*dto.ListJoinRequestsGroupResponse, error)
+	AcceptJoinRequest(ctx context.Context, in *dto.AcceptJoinRequestGroupRequest) (*dto.AcceptJoinRequestGroupResponse, error)
+	RejectJoinRequest(ctx context.Context, in *dto.RejectJoinRequestGroupRequest) (*dto.RejectJoinRequestGroupResponse, error)
+	Disband(ctx context.Context, in *dto.DisbandGroupRequest) (*dto.DisbandGroupResponse, error)
+	LockAll(ctx context.Context) error
 }
 
 type serviceImpl struct {
 	proto.UnimplementedGroupServiceServer
-	repo     Repository
-	userRepo user.Repository
-	cache    cache.Repository
-	conf     *config.GroupConfig
-	log      *zap.Logger
+	repo          Repository
+	userRepo      user.Repository
+	selectionRepo SelectionRepository
+	cache         cache.Aside
+	window        window.Window
+	conf          *config.GroupConfig
+	log           *zap.Logger
 }
 
-func NewService(repo Repository, userRepo user.Repository, cache cache.Repository, conf *config.GroupConfig, log *zap.Logger) Service {
+func NewService(repo Repository, userRepo user.Repository, selectionRepo SelectionRepository, cache cache.Aside, window window.Window, conf *config.GroupConfig, log *zap.Logger) Service {
 	return &serviceImpl{
-		repo:     repo,
-		userRepo: userRepo,
-		cache:    cache,
-		conf:     conf,
-		log:      log,
+		repo:          repo,
+		userRepo:      userRepo,
+		selectionRepo: selectionRepo,
+		cache:         cache,
+		window:        window,
+		conf:          conf,
+		log:           log,
 	}
 }
 
-func (s *serviceImpl) FindByUserId(_ context.Context, in *proto.FindByUserIdGroupRequest) (*proto.FindByUserIdGroupResponse, error) {
-	group, err := s.findByUserId(in.UserId)
+func (s *serviceImpl) FindByUserId(ctx context.Context, in *proto.FindByUserIdGroupRequest) (*proto.FindByUserIdGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.UserId); err != nil {
+		s.log.Named("FindByUserId").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	group, err := s.findByUserId(ctx, in.UserId)
 	if err != nil {
 		s.log.Named("FindByUserId").Error("findByUserId: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to find group")
@@ -51,26 +75,22 @@ func (s *serviceImpl) FindByUserId(_ context.Context, in *proto.FindByUserIdGrou
 	return &proto.FindByUserIdGroupResponse{Group: groupRPC}, nil
 }
 
-func (s *serviceImpl) findByUserId(userId string) (*model.Group, error) {
-	cacheKey := groupByUserIdKey(userId)
-	cachedGroup := &model.Group{}
-
-	if err := s.cache.GetValue(cacheKey, cachedGroup); err == nil {
-		s.log.Named("findByUserId").Info("GetValue: Group found in cache", zap.String("userId", userId))
-		return cachedGroup, nil
-	}
+func (s *serviceImpl) findByUserId(ctx context.Context, userId string) (*model.Group, error) {
+	group := &model.Group{}
+	err := s.cache.Load(ctx, GroupByUserIdKey(userId), group, s.conf.CacheTTL, func() error {
+		found, err := s.findByUserIdNoCache(userId)
+		if err != nil {
+			return err
+		}
+		*group = *found
 
-	group, err := s.findByUserIdNoCache(userId)
+		return nil
+	})
 	if err != nil {
 		s.log.Named("findByUserId").Error("findByUserIdNoCache group: ", zap.Error(err))
 		return nil, err
 	}
 
-	if err := s.cache.SetValue(cacheKey, group, s.conf.CacheTTL); err != nil {
-		s.log.Named("findByUserId").Error("SetValue: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to cache group")
-	}
-
 	return group, nil
 }
 
@@ -83,6 +103,16 @@ func (s *serviceImpl) findByUserIdNoCache(userId string) (*model.Group, error) {
 
 	if user.GroupID == nil {
 		err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+			if err := s.userRepo.FindOneForUpdateTX(tx, userId, user); err != nil {
+				s.log.Named("findByUserIdNoCache").Error("FindOneForUpdateTX user: ", zap.Error(err))
+				return fmt.Errorf("failed to find user: %w", err)
+			}
+
+			// another request created the group while we were waiting for the lock
+			if user.GroupID != nil {
+				return nil
+			}
+
 			createGroup := &model.Group{
 				LeaderID: &user.ID,
 			}
@@ -92,17 +122,18 @@ func (s *serviceImpl) findByUserIdNoCache(userId string) (*model.Group, error) {
 				return fmt.Errorf("failed to create new group: %w", err)
 			}
 
-			if err := s.userRepo.AssignGroupTX(tx, user.ID.String(), &createGroup.ID); err != nil {
-				s.log.Named("findByUserIdNoCache").Error("AssignGroupTX: ", zap.Error(err))
+			if err := s.assignGroupTX(tx, user.ID, &createGroup.ID); err != nil {
+				s.log.Named("findByUserIdNoCache").Error("assignGroupTX: ", zap.Error(err))
 				return fmt.Errorf("failed to assign user to group: %w", err)
 			}
+			user.GroupID = &createGroup.ID
 
-			return nil
+			return s.recordEventsTX(tx, &GroupEvent{GroupID: createGroup.ID, Type: constant.GROUP_CREATED, UserID: &user.ID, ActorID: &user.ID})
 		})
 
 		if err != nil {
 			s.log.Named("findByUserIdNoCache").Error("WithTransaction: ", zap.Error(err))
-			return nil, status.Error(codes.Internal, fmt.Sprintf("transaction failed: %s", err.Error()))
+			return nil, utils.TxStatusError(err)
 		}
 	}
 
@@ -115,9 +146,12 @@ func (s *serviceImpl) findByUserIdNoCache(userId string) (*model.Group, error) {
 	return group, nil
 }
 
-func (s *serviceImpl) FindByToken(_ context.Context, in *proto.FindByTokenGroupRequest) (*proto.FindByTokenGroupResponse, error) {
+func (s *serviceImpl) FindByToken(ctx context.Context, in *proto.FindByTokenGroupRequest) (*proto.FindByTokenGroupResponse, error) {
 	group := &model.Group{}
-	if err := s.repo.FindByToken(in.Token, group); err != nil {
+	err := s.cache.Load(ctx, GroupByTokenKey(in.Token), group, s.conf.CacheTTL, func() error {
+		return s.repo.FindByToken(in.Token, group)
+	})
+	if err != nil {
 		s.log.Named("FindByToken").Error("FindByToken: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, "failed to find group by token")
 	}
@@ -127,6 +161,16 @@ func (s *serviceImpl) FindByToken(_ context.Context, in *proto.FindByTokenGroupR
 		return nil, status.Error(codes.Internal, "group failed validation")
 	}
 
+	policy := &TokenPolicy{}
+	if err := s.repo.FindTokenPolicy(group.ID.String(), policy); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.log.Named("FindByToken").Error("FindTokenPolicy: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find token policy")
+	}
+	if err := checkTokenPolicy(policy); err != nil {
+		s.log.Named("FindByToken").Error("checkTokenPolicy: ", zap.Error(err))
+		return nil, err
+	}
+
 	var leader *model.User
 	for _, member := range group.Members {
 		if member.ID == *group.LeaderID {
@@ -145,77 +189,122 @@ func (s *serviceImpl) FindByToken(_ context.Context, in *proto.FindByTokenGroupR
 	return &res, nil
 }
 
-func (s *serviceImpl) UpdateConfirm(_ context.Context, in *proto.UpdateConfirmGroupRequest) (*proto.UpdateConfirmGroupResponse, error) {
-	group, err := s.findByUserId(in.LeaderId)
-	if err != nil {
-		s.log.Named("UpdateConfirm").Error("findByUserId: ", zap.Error(err))
+func (s *serviceImpl) UpdateConfirm(ctx context.Context, in *proto.UpdateConfirmGroupRequest) (*proto.UpdateConfirmGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("UpdateConfirm").Error("CheckSelf: ", zap.Error(err))
 		return nil, err
 	}
 
-	if err := s.checkGroup(group); err != nil {
-		s.log.Named("UpdateConfirm").Error("checkGroup: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "group failed validation")
+	if err := s.window.Check(); err != nil {
+		s.log.Named("UpdateConfirm").Error("Check window: ", zap.Error(err))
+		return nil, err
 	}
 
-	if group.LeaderID.String() != in.LeaderId {
-		s.log.Named("UpdateConfirm").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
-		return nil, status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+	if _, err := s.findByUserId(ctx, in.LeaderId); err != nil {
+		s.log.Named("UpdateConfirm").Error("findByUserId: ", zap.Error(err))
+		return nil, err
 	}
 
-	group.IsConfirmed = in.IsConfirmed
-	if err := s.repo.UpdateConfirm(group.ID.String(), group); err != nil {
-		s.log.Named("UpdateConfirm").Error("Update: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update group")
+	group := &model.Group{}
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		if err := s.findGroupForUpdateTX(tx, in.LeaderId, group); err != nil {
+			return err
+		}
+
+		if err := s.checkGroup(group); err != nil {
+			s.log.Named("UpdateConfirm").Error("checkGroup: ", zap.Error(err))
+			return status.Error(codes.Internal, "group failed validation")
+		}
+
+		if group.LeaderID.String() != in.LeaderId {
+			s.log.Named("UpdateConfirm").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+		}
+
+		if in.IsConfirmed && len(group.Members) < s.conf.MinSize {
+			s.log.Named("UpdateConfirm").Error("Group is too small to confirm", zap.Int("members", len(group.Members)))
+			return status.Error(codes.FailedPrecondition, fmt.Sprintf("group needs at least %v members to confirm", s.conf.MinSize))
+		}
+
+		group.IsConfirmed = in.IsConfirmed
+		if err := s.repo.UpdateConfirmTX(tx, group.ID.String(), group); err != nil {
+			s.log.Named("UpdateConfirm").Error("UpdateConfirmTX: ", zap.Error(err))
+			return status.Error(codes.Internal, "failed to update group")
+		}
+
+		if err := s.snapshotTX(tx, group.ID, group.IsConfirmed); err != nil {
+			return err
+		}
+
+		eventType := constant.GROUP_CONFIRMED
+		if !group.IsConfirmed {
+			eventType = constant.GROUP_UNCONFIRMED
+		}
+
+		return s.recordEventsTX(tx, &GroupEvent{GroupID: group.ID, Type: eventType, ActorID: group.LeaderID})
+	})
+
+	if err != nil {
+		s.log.Named("UpdateConfirm").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
 	}
 
-	if err := s.updateGroupCache(group); err != nil {
-		s.log.Named("UpdateConfirm").Error("updateGroupCacheByUserId: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update group cache")
+	if err := s.invalidateGroupCache(ctx, group); err != nil {
+		s.log.Named("UpdateConfirm").Error("invalidateGroupCache: ", zap.Error(err))
 	}
 	groupRPC := ModelToProto(group)
 
 	return &proto.UpdateConfirmGroupResponse{Group: groupRPC}, nil
 }
 
-func (s *serviceImpl) DeleteMember(_ context.Context, in *proto.DeleteMemberGroupRequest) (*proto.DeleteMemberGroupResponse, error) {
-	if in.LeaderId == in.UserId {
-		s.log.Named("DeleteMember").Error("User is the leader of the group", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.PermissionDenied, "You are the group leader, so you cannot delete yourself")
+func (s *serviceImpl) DeleteMember(ctx context.Context, in *proto.DeleteMemberGroupRequest) (*proto.DeleteMemberGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("DeleteMember").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
 	}
 
-	group, err := s.findByUserId(in.LeaderId)
-	if err != nil {
-		s.log.Named("DeleteMember").Error("findByUserId group: ", zap.Error(err))
+	if err := s.window.Check(); err != nil {
+		s.log.Named("DeleteMember").Error("Check window: ", zap.Error(err))
 		return nil, err
 	}
 
-	if group.IsConfirmed {
-		s.log.Named("DeleteMember").Error("Group is confirmed", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot delete member")
+	if in.LeaderId == in.UserId {
+		s.log.Named("DeleteMember").Error("User is the leader of the group", zap.String("user_id", in.UserId))
+		return nil, status.Error(codes.PermissionDenied, "You are the group leader, so you cannot delete yourself")
 	}
 
-	if in.LeaderId != group.LeaderID.String() {
-		s.log.Named("DeleteMember").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
-		return nil, status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
-	}
+	var groupId uuid.UUID
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		// lock the removed member before the group, the same order Join and Leave use
+		deletedUser := &model.User{}
+		if err := s.userRepo.FindOneForUpdateTX(tx, in.UserId, deletedUser); err != nil {
+			s.log.Named("DeleteMember").Error("FindOneForUpdateTX deletedUser: ", zap.Error(err))
+			return status.Error(codes.NotFound, "user_id to be deleted is not in the group")
+		}
 
-	var found bool
-	var deletedUserId *uuid.UUID
-	for _, member := range group.Members {
-		if member.ID.String() == in.UserId {
-			found = true
-			deletedUserId = &member.ID
-			break
+		group := &model.Group{}
+		if err := s.findGroupForUpdateTX(tx, in.LeaderId, group); err != nil {
+			return err
+		}
+		groupId = group.ID
+
+		if group.IsConfirmed {
+			s.log.Named("DeleteMember").Error("Group is confirmed", zap.String("user_id", in.UserId))
+			return status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot delete member")
+		}
+
+		if in.LeaderId != group.LeaderID.String() {
+			s.log.Named("DeleteMember").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+		}
+
+		if !isMember(group, in.UserId) {
+			s.log.Named("DeleteMember").Error("User is not in the group", zap.String("user_id", in.UserId))
+			return status.Error(codes.NotFound, "user_id to be deleted is not in the group")
 		}
-	}
-	if !found {
-		s.log.Named("DeleteMember").Error("User is not in the group", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.NotFound, "user_id to be deleted is not in the group")
-	}
 
-	err = s.repo.WithTransaction(func(tx *gorm.DB) error {
 		createGroup := &model.Group{
-			LeaderID: deletedUserId,
+			LeaderID: &deletedUser.ID,
 		}
 
 		if err := s.repo.CreateTX(tx, createGroup); err != nil {
@@ -223,17 +312,29 @@ func (s *serviceImpl) DeleteMember(_ context.Context, in *proto.DeleteMemberGrou
 			return fmt.Errorf("failed to create new group: %w", err)
 		}
 
-		if err := s.userRepo.AssignGroupTX(tx, in.UserId, &createGroup.ID); err != nil {
-			s.log.Named("DeleteMember").Error("AssignGroupTX: ", zap.Error(err))
+		if err := s.assignGroupTX(tx, deletedUser.ID, &createGroup.ID); err != nil {
+			s.log.Named("DeleteMember").Error("assignGroupTX: ", zap.Error(err))
 			return fmt.Errorf("failed to assign user to new group: %w", err)
 		}
 
+		err := s.recordEventsTX(tx,
+			&GroupEvent{GroupID: group.ID, Type: constant.GROUP_REMOVED, UserID: &deletedUser.ID, ActorID: group.LeaderID, RelatedGroupID: &createGroup.ID},
+			&GroupEvent{GroupID: createGroup.ID, Type: constant.GROUP_CREATED, UserID: &deletedUser.ID, ActorID: group.LeaderID},
+		)
+		if err != nil {
+			return err
+		}
+
+		if s.conf.SelectionPolicy == constant.SELECTION_RESET {
+			return s.resetSelectionsTX(tx, group, group.LeaderID)
+		}
+
 		return nil
 	})
 
 	if err != nil {
 		s.log.Named("DeleteMember").Error("WithTransaction: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("transaction failed: %s", err.Error()))
+		return nil, utils.TxStatusError(err)
 	}
 
 	newGroup, err := s.findByUserIdNoCache(in.UserId)
@@ -241,19 +342,17 @@ func (s *serviceImpl) DeleteMember(_ context.Context, in *proto.DeleteMemberGrou
 		s.log.Named("DeleteMember").Error("findByUserIdNoCache newGroup: ", zap.Error(err))
 		return nil, err
 	}
-	updatedGroup, err := s.findByUserIdNoCache(in.LeaderId)
-	if err != nil {
-		s.log.Named("DeleteMember").Error("findByUserIdNoCache updatedGroup: ", zap.Error(err))
-		return nil, err
+	updatedGroup := &model.Group{}
+	if err := s.repo.FindOne(groupId.String(), updatedGroup); err != nil {
+		s.log.Named("DeleteMember").Error("FindOne updatedGroup: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find group")
 	}
 
-	if err := s.updateGroupCache(newGroup); err != nil {
-		s.log.Named("DeleteMember").Error("updateGroupCacheByUserId: newGroup", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update newGroup cache")
+	if err := s.invalidateGroupCache(ctx, newGroup); err != nil {
+		s.log.Named("DeleteMember").Error("invalidateGroupCache: newGroup", zap.Error(err))
 	}
-	if err := s.updateGroupCache(updatedGroup); err != nil {
-		s.log.Named("DeleteMember").Error("updateGroupCacheByUserId: updatedGroup", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update updatedGroup cache")
+	if err := s.invalidateGroupCache(ctx, updatedGroup); err != nil {
+		s.log.Named("DeleteMember").Error("invalidateGroupCache: updatedGroup", zap.Error(err))
 	}
 
 	groupRPC := ModelToProto(updatedGroup)
@@ -261,35 +360,58 @@ func (s *serviceImpl) DeleteMember(_ context.Context, in *proto.DeleteMemberGrou
 	return &proto.DeleteMemberGroupResponse{Group: groupRPC}, nil
 }
 
-func (s *serviceImpl) Leave(_ context.Context, in *proto.LeaveGroupRequest) (*proto.LeaveGroupResponse, error) {
-	group, err := s.findByUserId(in.UserId)
-	if err != nil {
-		s.log.Named("Leave").Error("findByUserId group: ", zap.Error(err))
+func (s *serviceImpl) Leave(ctx context.Context, in *proto.LeaveGroupRequest) (*proto.LeaveGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.UserId); err != nil {
+		s.log.Named("Leave").Error("CheckSelf: ", zap.Error(err))
 		return nil, err
 	}
 
-	if group.IsConfirmed {
-		s.log.Named("Leave").Error("Group is confirmed", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot leave")
+	if err := s.window.Check(); err != nil {
+		s.log.Named("Leave").Error("Check window: ", zap.Error(err))
+		return nil, err
 	}
 
-	if in.UserId == group.LeaderID.String() {
-		s.log.Named("Leave").Error("User is the leader of the group", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.PermissionDenied, "You are the group leader, so you cannot leave")
+	if _, err := s.findByUserId(ctx, in.UserId); err != nil {
+		s.log.Named("Leave").Error("findByUserId group: ", zap.Error(err))
+		return nil, err
 	}
 
-	if len(group.Members) == 1 {
-		s.log.Named("Leave").Error("Group has only one member", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.PermissionDenied, "You are the only member in the group, so you cannot leave")
-	}
+	var groupId uuid.UUID
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		group := &model.Group{}
+		if err := s.findGroupForUpdateTX(tx, in.UserId, group); err != nil {
+			return err
+		}
+		groupId = group.ID
 
-	userId, err := uuid.Parse(in.UserId)
-	if err != nil {
-		s.log.Named("Leave").Error("Parse userId: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to parse user id")
-	}
+		if group.IsConfirmed {
+			s.log.Named("Leave").Error("Group is confirmed", zap.String("user_id", in.UserId))
+			return status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot leave")
+		}
+
+		isLeader := in.UserId == group.LeaderID.String()
+		if isLeader && !s.conf.LeaderSuccession {
+			s.log.Named("Leave").Error("User is the leader of the group", zap.String("user_id", in.UserId))
+			return status.Error(codes.PermissionDenied, "You are the group leader, so you cannot leave")
+		}
+
+		if len(group.Members) == 1 {
+			s.log.Named("Leave").Error("Group has only one member", zap.String("user_id", in.UserId))
+			return status.Error(codes.PermissionDenied, "You are the only member in the group, so you cannot leave")
+		}
+
+		userId, err := uuid.Parse(in.UserId)
+		if err != nil {
+			s.log.Named("Leave").Error("Parse userId: ", zap.Error(err))
+			return status.Error(codes.Internal, "failed to parse user id")
+		}
+
+		if isLeader {
+			if err := s.passLeadershipTX(tx, group); err != nil {
+				return err
+			}
+		}
 
-	err = s.repo.WithTransaction(func(tx *gorm.DB) error {
 		createGroup := &model.Group{
 			LeaderID: &userId,
 		}
@@ -299,17 +421,29 @@ func (s *serviceImpl) Leave(_ context.Context, in *proto.LeaveGroupRequest) (*pr
 			return fmt.Errorf("failed to create new group: %w", err)
 		}
 
-		if err := s.userRepo.AssignGroupTX(tx, in.UserId, &createGroup.ID); err != nil {
-			s.log.Named("Leave").Error("AssignGroupTX: ", zap.Error(err))
+		if err := s.assignGroupTX(tx, userId, &createGroup.ID); err != nil {
+			s.log.Named("Leave").Error("assignGroupTX: ", zap.Error(err))
 			return fmt.Errorf("failed to assign user to new group: %w", err)
 		}
 
+		err = s.recordEventsTX(tx,
+			&GroupEvent{GroupID: group.ID, Type: constant.GROUP_LEFT, UserID: &userId, ActorID: &userId, RelatedGroupID: &createGroup.ID},
+			&GroupEvent{GroupID: createGroup.ID, Type: constant.GROUP_CREATED, UserID: &userId, ActorID: &userId},
+		)
+		if err != nil {
+			return err
+		}
+
+		if s.conf.SelectionPolicy == constant.SELECTION_RESET {
+			return s.resetSelectionsTX(tx, group, &userId)
+		}
+
 		return nil
 	})
 
 	if err != nil {
 		s.log.Named("Leave").Error("WithTransaction: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("transaction failed: %s", err.Error()))
+		return nil, utils.TxStatusError(err)
 	}
 
 	newGroup, err := s.findByUserIdNoCache(in.UserId)
@@ -317,19 +451,17 @@ func (s *serviceImpl) Leave(_ context.Context, in *proto.LeaveGroupRequest) (*pr
 		s.log.Named("Leave").Error("findByUserIdNoCache group: ", zap.Error(err))
 		return nil, err
 	}
-	updatedGroup, err := s.findByUserIdNoCache(group.LeaderID.String())
-	if err != nil {
-		s.log.Named("Leave").Error("findByUserIdNoCache group: ", zap.Error(err))
-		return nil, err
+	updatedGroup := &model.Group{}
+	if err := s.repo.FindOne(groupId.String(), updatedGroup); err != nil {
+		s.log.Named("Leave").Error("FindOne updatedGroup: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find group")
 	}
 
-	if err := s.updateGroupCache(newGroup); err != nil {
-		s.log.Named("Leave").Error("updateGroupCacheByUserId: newGroup", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update newGroup cache")
+	if err := s.invalidateGroupCache(ctx, newGroup); err != nil {
+		s.log.Named("Leave").Error("invalidateGroupCache: newGroup", zap.Error(err))
 	}
-	if err := s.updateGroupCache(updatedGroup); err != nil {
-		s.log.Named("Leave").Error("updateGroupCacheByUserId: updatedGroup", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update updatedGroup cache")
+	if err := s.invalidateGroupCache(ctx, updatedGroup); err != nil {
+		s.log.Named("Leave").Error("invalidateGroupCache: updatedGroup", zap.Error(err))
 	}
 
 	groupRPC := ModelToProto(updatedGroup)
@@ -337,97 +469,958 @@ func (s *serviceImpl) Leave(_ context.Context, in *proto.LeaveGroupRequest) (*pr
 	return &proto.LeaveGroupResponse{Group: groupRPC}, nil
 }
 
-func (s *serviceImpl) Join(_ context.Context, in *proto.JoinGroupRequest) (*proto.JoinGroupResponse, error) {
-	group, err := s.findByUserId(in.UserId)
-	if err != nil {
+// Join moves the user into the group of the token. When join approval is enabled it only
+// files a join request for the leader to accept, and returns the user's current group.
+func (s *serviceImpl) Join(ctx context.Context, in *proto.JoinGroupRequest) (*proto.JoinGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.UserId); err != nil {
+		s.log.Named("Join").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.window.Check(); err != nil {
+		s.log.Named("Join").Error("Check window: ", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := s.findByUserId(ctx, in.UserId); err != nil {
 		s.log.Named("Join").Error("findByUserId group: ", zap.Error(err))
 		return nil, err
 	}
 
-	if group.IsConfirmed {
-		s.log.Named("Join").Error("Group is confirmed", zap.String("user_id", in.UserId))
-		return nil, status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot leave to join other groups")
+	userId, err := uuid.Parse(in.UserId)
+	if err != nil {
+		s.log.Named("Join").Error("Parse userId: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to parse user id")
 	}
 
+	prevGroup := &model.Group{}
 	joiningGroup := &model.Group{}
-	if err := s.repo.FindByToken(in.Token, joiningGroup); err != nil {
-		s.log.Named("Join").Error("FindByToken joiningGroup TX: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to find joining group by token")
+	err = s.repo.WithTransaction(func(tx *gorm.DB) error {
+		isLeader, err := s.lockPrevGroupTX(tx, in.UserId, prevGroup)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.FindByTokenForUpdateTX(tx, in.Token, joiningGroup); err != nil {
+			s.log.Named("Join").Error("FindByTokenForUpdateTX joiningGroup: ", zap.Error(err))
+			return status.Error(codes.Internal, "failed to find joining group by token")
+		}
+
+		// a join request counts its use once it is accepted, so rejected or expired ones cost nothing
+		if err := s.useTokenTX(tx, joiningGroup, !s.conf.JoinApproval); err != nil {
+			return err
+		}
+
+		if err := s.checkJoiningGroup(in.UserId, prevGroup, joiningGroup); err != nil {
+			return err
+		}
+
+		if s.conf.JoinApproval {
+			return s.createJoinRequestTX(tx, in.UserId, joiningGroup)
+		}
+
+		return s.moveToGroupTX(tx, userId, userId, isLeader, prevGroup, joiningGroup)
+	})
+
+	if err != nil {
+		s.log.Named("Join").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if s.conf.JoinApproval {
+		return &proto.JoinGroupResponse{Group: ModelToProto(prevGroup)}, nil
+	}
+
+	joinedGroup, err := s.refreshJoinCache(ctx, joiningGroup.ID.String(), prevGroup)
+	if err != nil {
+		s.log.Named("Join").Error("refreshJoinCache: ", zap.Error(err))
+		return nil, err
+	}
+
+	groupRPC := ModelToProto(joinedGroup)
+
+	return &proto.JoinGroupResponse{Group: groupRPC}, nil
+}
+
+// ListJoinRequests returns the pending join requests of the leader's group, dropping expired ones
+func (s *serviceImpl) ListJoinRequests(ctx context.Context, in *dto.ListJoinRequestsGroupRequest) (*dto.ListJoinRequestsGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("ListJoinRequests").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	group, err := s.findByUserId(ctx, in.LeaderId)
+	if err != nil {
+		s.log.Named("ListJoinRequests").Error("findByUserId: ", zap.Error(err))
+		return nil, err
+	}
+
+	if group.LeaderID.String() != in.LeaderId {
+		s.log.Named("ListJoinRequests").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+		return nil, status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+	}
+
+	if err := s.repo.DeleteExpiredJoinRequests(group.ID.String()); err != nil {
+		s.log.Named("ListJoinRequests").Error("DeleteExpiredJoinRequests: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to delete expired join requests")
+	}
+
+	joinRequests := []JoinRequest{}
+	if err := s.repo.FindJoinRequestsByGroupId(group.ID.String(), &joinRequests); err != nil {
+		s.log.Named("ListJoinRequests").Error("FindJoinRequestsByGroupId: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find join requests")
+	}
+
+	joinRequestsDTO := make([]*dto.JoinRequest, len(joinRequests))
+	for i := range joinRequests {
+		joinRequestsDTO[i] = JoinRequestToDTO(&joinRequests[i])
+	}
+
+	return &dto.ListJoinRequestsGroupResponse{JoinRequests: joinRequestsDTO}, nil
+}
+
+// AcceptJoinRequest moves the requester into the leader's group with the same checks as Join
+func (s *serviceImpl) AcceptJoinRequest(ctx context.Context, in *dto.AcceptJoinRequestGroupRequest) (*dto.AcceptJoinRequestGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("AcceptJoinRequest").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.window.Check(); err != nil {
+		s.log.Named("AcceptJoinRequest").Error("Check window: ", zap.Error(err))
+		return nil, err
+	}
+
+	joinRequest := &JoinRequest{}
+	if err := s.repo.FindJoinRequest(in.RequestId, joinRequest); err != nil {
+		s.log.Named("AcceptJoinRequest").Error("FindJoinRequest: ", zap.Error(err))
+		return nil, status.Error(codes.NotFound, "join request not found")
+	}
+	userId := joinRequest.UserID.String()
+
+	if _, err := s.findByUserId(ctx, userId); err != nil {
+		s.log.Named("AcceptJoinRequest").Error("findByUserId: ", zap.Error(err))
+		return nil, err
 	}
 
-	for _, member := range joiningGroup.Members {
-		if member.ID.String() == in.UserId {
-			s.log.Named("Join").Error("User is already in the group", zap.String("user_id", in.UserId))
-			return nil, status.Error(codes.PermissionDenied, "user is already in the group")
+	prevGroup := &model.Group{}
+	joiningGroup := &model.Group{}
+	expired := false
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		isLeader, err := s.lockPrevGroupTX(tx, userId, prevGroup)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.FindOneForUpdateTX(tx, joinRequest.GroupID.String(), joiningGroup); err != nil {
+			s.log.Named("AcceptJoinRequest").Error("FindOneForUpdateTX joiningGroup: ", zap.Error(err))
+			return status.Error(codes.Internal, "failed to find joining group")
+		}
+
+		if joiningGroup.LeaderID.String() != in.LeaderId {
+			s.log.Named("AcceptJoinRequest").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
 		}
+
+		// the request may have been handled while we were waiting for the locks
+		if err := s.repo.DeleteJoinRequestTX(tx, in.RequestId); err != nil {
+			s.log.Named("AcceptJoinRequest").Error("DeleteJoinRequestTX: ", zap.Error(err))
+			return status.Error(codes.NotFound, "join request not found")
+		}
+
+		// the expired request stays deleted, so the error is returned after the commit
+		if joinRequest.isExpired() {
+			expired = true
+			return nil
+		}
+
+		if err := s.checkJoiningGroup(userId, prevGroup, joiningGroup); err != nil {
+			return err
+		}
+
+		if err := s.countTokenUseTX(tx, joiningGroup); err != nil {
+			return err
+		}
+
+		return s.moveToGroupTX(tx, joinRequest.UserID, *joiningGroup.LeaderID, isLeader, prevGroup, joiningGroup)
+	})
+
+	if err != nil {
+		s.log.Named("AcceptJoinRequest").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
 	}
 
-	if len(joiningGroup.Members) >= s.conf.Capacity {
-		s.log.Named("Join").Error("Group is full", zap.String("token", in.Token))
-		return nil, status.Error(codes.PermissionDenied, "group is full")
+	if expired {
+		s.log.Named("AcceptJoinRequest").Error("Join request is expired", zap.String("request_id", in.RequestId))
+		return nil, status.Error(codes.FailedPrecondition, "join request has expired")
 	}
 
-	prevGroup, err := s.findByUserId(in.UserId)
+	joinedGroup, err := s.refreshJoinCache(ctx, joiningGroup.ID.String(), prevGroup)
 	if err != nil {
-		s.log.Named("Join").Error("findByUserId prevGroup: ", zap.Error(err))
+		s.log.Named("AcceptJoinRequest").Error("refreshJoinCache: ", zap.Error(err))
 		return nil, err
 	}
 
-	err = s.repo.WithTransaction(func(tx *gorm.DB) error {
+	return &dto.AcceptJoinRequestGroupResponse{Group: ModelToProto(joinedGroup)}, nil
+}
+
+func (s *serviceImpl) RejectJoinRequest(ctx context.Context, in *dto.RejectJoinRequestGroupRequest) (*dto.RejectJoinRequestGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("RejectJoinRequest").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		group := &model.Group{}
+		if err := s.findGroupForUpdateTX(tx, in.LeaderId, group); err != nil {
+			return err
+		}
+
+		if group.LeaderID.String() != in.LeaderId {
+			s.log.Named("RejectJoinRequest").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+		}
+
+		joinRequest := &JoinRequest{}
+		if err := s.repo.FindJoinRequest(in.RequestId, joinRequest); err != nil {
+			s.log.Named("RejectJoinRequest").Error("FindJoinRequest: ", zap.Error(err))
+			return status.Error(codes.NotFound, "join request not found")
+		}
 
-		if err := s.userRepo.AssignGroupTX(tx, in.UserId, &joiningGroup.ID); err != nil {
-			s.log.Named("findByUserId").Error("AssignGroupTX: ", zap.Error(err))
-			return fmt.Errorf("failed to assign user to group: %w", err)
+		if joinRequest.GroupID != group.ID {
+			s.log.Named("RejectJoinRequest").Error("Join request is for another group", zap.String("request_id", in.RequestId))
+			return status.Error(codes.NotFound, "join request not found")
 		}
 
-		if in.UserId == prevGroup.LeaderID.String() && len(prevGroup.Members) == 1 {
-			err := s.repo.DeleteGroupTX(tx, &prevGroup.ID)
+		if err := s.repo.DeleteJoinRequestTX(tx, in.RequestId); err != nil {
+			s.log.Named("RejectJoinRequest").Error("DeleteJoinRequestTX: ", zap.Error(err))
+			return status.Error(codes.NotFound, "join request not found")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		s.log.Named("RejectJoinRequest").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	return &dto.RejectJoinRequestGroupResponse{Success: true}, nil
+}
+
+// Disband moves every member except the leader into a fresh solo group and clears the group's selections
+func (s *serviceImpl) Disband(ctx context.Context, in *dto.DisbandGroupRequest) (*dto.DisbandGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("Disband").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.window.Check(); err != nil {
+		s.log.Named("Disband").Error("Check window: ", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := s.findByUserId(ctx, in.LeaderId); err != nil {
+		s.log.Named("Disband").Error("findByUserId: ", zap.Error(err))
+		return nil, err
+	}
+
+	var groupId uuid.UUID
+	var removedIds []string
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		// the group lock keeps the member list fixed, every membership change takes it
+		group := &model.Group{}
+		if err := s.findGroupForUpdateTX(tx, in.LeaderId, group); err != nil {
+			return err
+		}
+		groupId = group.ID
+
+		if in.LeaderId != group.LeaderID.String() {
+			s.log.Named("Disband").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+		}
+
+		if group.IsConfirmed {
+			s.log.Named("Disband").Error("Group is confirmed", zap.String("group_id", group.ID.String()))
+			return status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot disband it")
+		}
+
+		removedIds = nil
+		for _, member := range group.Members {
+			if member.ID == *group.LeaderID {
+				continue
+			}
+
+			createGroup := &model.Group{
+				LeaderID: &member.ID,
+			}
+
+			if err := s.repo.CreateTX(tx, createGroup); err != nil {
+				s.log.Named("Disband").Error("CreateTX: ", zap.Error(err))
+				return fmt.Errorf("failed to create new group: %w", err)
+			}
+
+			if err := s.assignGroupTX(tx, member.ID, &createGroup.ID); err != nil {
+				s.log.Named("Disband").Error("assignGroupTX: ", zap.Error(err))
+				return fmt.Errorf("failed to assign user to new group: %w", err)
+			}
+
+			err := s.recordEventsTX(tx,
+				&GroupEvent{GroupID: group.ID, Type: constant.GROUP_REMOVED, UserID: &member.ID, ActorID: group.LeaderID, RelatedGroupID: &createGroup.ID},
+				&GroupEvent{GroupID: createGroup.ID, Type: constant.GROUP_CREATED, UserID: &member.ID, ActorID: group.LeaderID},
+			)
 			if err != nil {
-				s.log.Named("Join").Error("DeleteGroupTX: ", zap.Error(err))
-				return fmt.Errorf("failed to delete old group: %w", err)
+				return err
 			}
+			removedIds = append(removedIds, member.ID.String())
+		}
+
+		if err := s.selectionRepo.DeleteByGroupIdTX(tx, group.ID.String()); err != nil {
+			s.log.Named("Disband").Error("DeleteByGroupIdTX: ", zap.Error(err))
+			return fmt.Errorf("failed to delete selections: %w", err)
 		}
 
 		return nil
 	})
 
 	if err != nil {
-		s.log.Named("Join").Error("WithTransaction: ", zap.Error(err))
-		return nil, status.Error(codes.Internal, fmt.Sprintf("transaction failed: %s", err.Error()))
+		s.log.Named("Disband").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
 	}
 
-	joiningGroup.Members = append(joiningGroup.Members, prevGroup.Members[0])
+	for _, userId := range removedIds {
+		newGroup, err := s.findByUserIdNoCache(userId)
+		if err != nil {
+			s.log.Named("Disband").Error("findByUserIdNoCache newGroup: ", zap.Error(err))
+			return nil, err
+		}
+		if err := s.invalidateGroupCache(ctx, newGroup); err != nil {
+			s.log.Named("Disband").Error("invalidateGroupCache: newGroup", zap.Error(err))
+		}
+	}
 
-	if err := s.updateGroupCache(joiningGroup); err != nil {
-		s.log.Named("Join").Error("updateGroupCacheByUserId: joiningGroup", zap.Error(err))
-		return nil, status.Error(codes.Internal, "failed to update joiningGroup cache")
+	updatedGroup := &model.Group{}
+	if err := s.repo.FindOne(groupId.String(), updatedGroup); err != nil {
+		s.log.Named("Disband").Error("FindOne updatedGroup: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find group")
+	}
+	if err := s.invalidateGroupCache(ctx, updatedGroup); err != nil {
+		s.log.Named("Disband").Error("invalidateGroupCache: updatedGroup", zap.Error(err))
 	}
 
-	groupRPC := ModelToProto(joiningGroup)
+	return &dto.DisbandGroupResponse{Group: ModelToProto(updatedGroup)}, nil
+}
 
-	return &proto.JoinGroupResponse{Group: groupRPC}, nil
+// lockPrevGroupTX locks the user and the group they are leaving, and checks they are allowed to leave it
+func (s *serviceImpl) lockPrevGroupTX(tx *gorm.DB, userId string, prevGroup *model.Group) (isLeader bool, err error) {
+	if err := s.findGroupForUpdateTX(tx, userId, prevGroup); err != nil {
+		return false, err
+	}
+
+	if prevGroup.IsConfirmed {
+		s.log.Named("lockPrevGroupTX").Error("Group is confirmed", zap.String("user_id", userId))
+		return false, status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot leave to join other groups")
+	}
+
+	isLeader = userId == prevGroup.LeaderID.String()
+	if isLeader && len(prevGroup.Members) > 1 {
+		s.log.Named("lockPrevGroupTX").Error("User is the leader of a group with other members", zap.String("user_id", userId))
+		return false, status.Error(codes.PermissionDenied, "You are the group leader, so you cannot leave to join other groups")
+	}
+
+	return isLeader, nil
+}
+
+func (s *serviceImpl) checkJoiningGroup(userId string, prevGroup *model.Group, joiningGroup *model.Group) error {
+	if joiningGroup.ID == prevGroup.ID {
+		s.log.Named("checkJoiningGroup").Error("User is already in the group", zap.String("user_id", userId))
+		return status.Error(codes.PermissionDenied, "user is already in the group")
+	}
+
+	if joiningGroup.IsConfirmed {
+		s.log.Named("checkJoiningGroup").Error("Joining group is confirmed", zap.String("group_id", joiningGroup.ID.String()))
+		return status.Error(codes.PermissionDenied, "group is confirmed")
+	}
+
+	// the joiner's cohort counts towards the size the group may reach
+	members := append([]*model.User{}, joiningGroup.Members...)
+	for _, member := range prevGroup.Members {
+		if member.ID.String() == userId {
+			members = append(members, member)
+		}
+	}
+	if len(joiningGroup.Members) >= Capacity(s.conf, members) {
+		s.log.Named("checkJoiningGroup").Error("Group is full", zap.String("group_id", joiningGroup.ID.String()))
+		return status.Error(codes.PermissionDenied, "group is full")
+	}
+
+	return nil
+}
+
+// moveToGroupTX assigns the user to the joining group, deleting their previous group if they led it alone,
+// applies the selection policy and withdraws their pending join requests
+func (s *serviceImpl) moveToGroupTX(tx *gorm.DB, userId uuid.UUID, actorId uuid.UUID, isLeader bool, prevGroup *model.Group, joiningGroup *model.Group) error {
+	if err := s.assignGroupTX(tx, userId, &joiningGroup.ID); err != nil {
+		s.log.Named("moveToGroupTX").Error("assignGroupTX: ", zap.Error(err))
+		return fmt.Errorf("failed to assign user to group: %w", err)
+	}
+
+	err := s.recordEventsTX(tx,
+		&GroupEvent{GroupID: prevGroup.ID, Type: constant.GROUP_LEFT, UserID: &userId, ActorID: &actorId, RelatedGroupID: &joiningGroup.ID},
+		&GroupEvent{GroupID: joiningGroup.ID, Type: constant.GROUP_JOINED, UserID: &userId, ActorID: &actorId, RelatedGroupID: &prevGroup.ID},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteJoinRequestsByUserIdTX(tx, userId.String()); err != nil {
+		s.log.Named("moveToGroupTX").Error("DeleteJoinRequestsByUserIdTX: ", zap.Error(err))
+		return fmt.Errorf("failed to delete join requests: %w", err)
+	}
+
+	if s.conf.SelectionPolicy == constant.SELECTION_RESET {
+		if err := s.resetSelectionsTX(tx, joiningGroup, &actorId); err != nil {
+			return err
+		}
+		if !isLeader {
+			if err := s.resetSelectionsTX(tx, prevGroup, &actorId); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isLeader {
+		if s.conf.SelectionPolicy == constant.SELECTION_KEEP {
+			if err := s.carrySelectionsTX(tx, prevGroup, joiningGroup); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.DeleteGroupTX(tx, &prevGroup.ID); err != nil {
+			s.log.Named("moveToGroupTX").Error("DeleteGroupTX: ", zap.Error(err))
+			return fmt.Errorf("failed to delete old group: %w", err)
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// createJoinRequestTX files a join request, replacing any other pending request of the user
+func (s *serviceImpl) createJoinRequestTX(tx *gorm.DB, userId string, joiningGroup *model.Group) error {
+	if err := s.repo.DeleteJoinRequestsByUserIdTX(tx, userId); err != nil {
+		s.log.Named("createJoinRequestTX").Error("DeleteJoinRequestsByUserIdTX: ", zap.Error(err))
+		return fmt.Errorf("failed to delete join requests: %w", err)
+	}
+
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		s.log.Named("createJoinRequestTX").Error("Parse userId: ", zap.Error(err))
+		return status.Error(codes.Internal, "failed to parse user id")
+	}
+
+	joinRequest := &JoinRequest{
+		GroupID: joiningGroup.ID,
+		UserID:  id,
+	}
+	if s.conf.JoinRequestTTL > 0 {
+		expiresAt := time.Now().Add(time.Duration(s.conf.JoinRequestTTL) * time.Second)
+		joinRequest.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.CreateJoinRequestTX(tx, joinRequest); err != nil {
+		s.log.Named("createJoinRequestTX").Error("CreateJoinRequestTX: ", zap.Error(err))
+		return fmt.Errorf("failed to create join request: %w", err)
+	}
+
+	return nil
+}
+
+// refreshJoinCache refetches the joined group and invalidates the cache of it and the group the user left.
+// prevGroup is the group as it was before the move, so a deleted group's token is invalidated too.
+func (s *serviceImpl) refreshJoinCache(ctx context.Context, joinedGroupId string, prevGroup *model.Group) (*model.Group, error) {
+	joinedGroup := &model.Group{}
+	if err := s.repo.FindOne(joinedGroupId, joinedGroup); err != nil {
+		s.log.Named("refreshJoinCache").Error("FindOne joinedGroup: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to find joined group")
+	}
+
+	if err := s.invalidateGroupCache(ctx, joinedGroup, prevGroup); err != nil {
+		s.log.Named("refreshJoinCache").Error("invalidateGroupCache: ", zap.Error(err))
+	}
+
+	return joinedGroup, nil
+}
+
+// TransferLeadership hands leadership of the group to another member, after which the old leader may leave
+func (s *serviceImpl) TransferLeadership(ctx context.Context, in *dto.TransferLeadershipGroupRequest) (*dto.TransferLeadershipGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("TransferLeadership").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.window.Check(); err != nil {
+		s.log.Named("TransferLeadership").Error("Check window: ", zap.Error(err))
+		return nil, err
+	}
+
+	if in.LeaderId == in.NewLeaderId {
+		s.log.Named("TransferLeadership").Error("New leader is the current leader", zap.String("leader_id", in.LeaderId))
+		return nil, status.Error(codes.InvalidArgument, "you are already the group leader")
+	}
+
+	if _, err := s.findByUserId(ctx, in.LeaderId); err != nil {
+		s.log.Named("TransferLeadership").Error("findByUserId: ", zap.Error(err))
+		return nil, err
+	}
+
+	group := &model.Group{}
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		if err := s.findGroupForUpdateTX(tx, in.LeaderId, group); err != nil {
+			return err
+		}
+
+		// the snapshot of a confirmed group records its leader for allocation
+		if group.IsConfirmed {
+			s.log.Named("TransferLeadership").Error("Group is confirmed", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "Group is confirmed, so you cannot transfer leadership")
+		}
+
+		if group.LeaderID.String() != in.LeaderId {
+			s.log.Named("TransferLeadership").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+		}
+
+		var newLeader *model.User
+		for _, member := range group.Members {
+			if member.ID.String() == in.NewLeaderId {
+				newLeader = member
+				break
+			}
+		}
+		if newLeader == nil {
+			s.log.Named("TransferLeadership").Error("New leader is not in the group", zap.String("new_leader_id", in.NewLeaderId))
+			return status.Error(codes.NotFound, "new_leader_id is not in the group")
+		}
+
+		if err := s.repo.UpdateLeaderTX(tx, group.ID.String(), &newLeader.ID); err != nil {
+			s.log.Named("TransferLeadership").Error("UpdateLeaderTX: ", zap.Error(err))
+			return fmt.Errorf("failed to update group leader: %w", err)
+		}
+		oldLeaderId := group.LeaderID
+		group.LeaderID = &newLeader.ID
+
+		return s.recordEventsTX(tx, &GroupEvent{GroupID: group.ID, Type: constant.GROUP_LEADER_CHANGED, UserID: &newLeader.ID, ActorID: oldLeaderId})
+	})
+
+	if err != nil {
+		s.log.Named("TransferLeadership").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if err := s.invalidateGroupCache(ctx, group); err != nil {
+		s.log.Named("TransferLeadership").Error("invalidateGroupCache: ", zap.Error(err))
+	}
+
+	return &dto.TransferLeadershipGroupResponse{Group: ModelToProto(group)}, nil
+}
+
+// RotateToken replaces the invite token of the leader's group, so the old join link stops working.
+// The new token can optionally expire at a given time or after a number of joins.
+func (s *serviceImpl) RotateToken(ctx context.Context, in *dto.RotateTokenGroupRequest) (*dto.RotateTokenGroupResponse, error) {
+	if err := auth.CheckSelf(ctx, in.LeaderId); err != nil {
+		s.log.Named("RotateToken").Error("CheckSelf: ", zap.Error(err))
+		return nil, err
+	}
+
+	if in.MaxUses < 0 {
+		s.log.Named("RotateToken").Error("Invalid max_uses", zap.Int("max_uses", in.MaxUses))
+		return nil, status.Error(codes.InvalidArgument, "max_uses must not be negative")
+	}
+
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		s.log.Named("RotateToken").Error("Invalid expires_at", zap.Time("expires_at", *in.ExpiresAt))
+		return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+	}
+
+	if _, err := s.findByUserId(ctx, in.LeaderId); err != nil {
+		s.log.Named("RotateToken").Error("findByUserId: ", zap.Error(err))
+		return nil, err
+	}
+
+	group := &model.Group{}
+	var oldToken string
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		if err := s.findGroupForUpdateTX(tx, in.LeaderId, group); err != nil {
+			return err
+		}
+
+		if group.LeaderID.String() != in.LeaderId {
+			s.log.Named("RotateToken").Error("Requested leader_id is not leader of this group", zap.String("leader_id", in.LeaderId))
+			return status.Error(codes.PermissionDenied, "requested leader_id is not leader of this group")
+		}
+
+		oldToken = group.Token
+		group.Token = modelUtils.GenGroupToken(group.LeaderID)
+		if err := s.repo.UpdateTokenTX(tx, group.ID.String(), group.Token); err != nil {
+			s.log.Named("RotateToken").Error("UpdateTokenTX: ", zap.Error(err))
+			return fmt.Errorf("failed to update group token: %w", err)
+		}
+
+		policy := &TokenPolicy{
+			GroupID:   group.ID,
+			ExpiresAt: in.ExpiresAt,
+			MaxUses:   in.MaxUses,
+		}
+		if err := s.repo.SaveTokenPolicyTX(tx, policy); err != nil {
+			s.log.Named("RotateToken").Error("SaveTokenPolicyTX: ", zap.Error(err))
+			return fmt.Errorf("failed to save token policy: %w", err)
+		}
+
+		return s.recordEventsTX(tx, &GroupEvent{GroupID: group.ID, Type: constant.GROUP_TOKEN_ROTATED, ActorID: group.LeaderID})
+	})
+
+	if err != nil {
+		s.log.Named("RotateToken").Error("WithTransaction: ", zap.Error(err))
+		return nil, utils.TxStatusError(err)
+	}
+
+	if err := s.cache.Invalidate(ctx, GroupByTokenKey(oldToken)); err != nil {
+		s.log.Named("RotateToken").Error("Invalidate groupByToken: ", zap.Error(err))
+	}
+	if err := s.invalidateGroupCache(ctx, group); err != nil {
+		s.log.Named("RotateToken").Error("invalidateGroupCache: ", zap.Error(err))
+	}
+
+	return &dto.RotateTokenGroupResponse{
+		Group:     ModelToProto(group),
+		ExpiresAt: in.ExpiresAt,
+		MaxUses:   in.MaxUses,
+	}, nil
 }
 
-func (s *serviceImpl) updateGroupCache(group *model.Group) error {
+// useTokenTX checks the token policy of the locked joining group and, when count is set, counts the
+// join against it
+func (s *serviceImpl) useTokenTX(tx *gorm.DB, group *model.Group, count bool) error {
+	policy := &TokenPolicy{}
+	if err := s.repo.FindTokenPolicyTX(tx, group.ID.String(), policy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		s.log.Named("useTokenTX").Error("FindTokenPolicyTX: ", zap.Error(err))
+		return fmt.Errorf("failed to find token policy: %w", err)
+	}
+
+	if err := checkTokenPolicy(policy); err != nil {
+		s.log.Named("useTokenTX").Error("checkTokenPolicy: ", zap.Error(err))
+		return err
+	}
+
+	if !count {
+		return nil
+	}
+
+	if err := s.repo.IncrementTokenUsesTX(tx, group.ID.String()); err != nil {
+		s.log.Named("useTokenTX").Error("IncrementTokenUsesTX: ", zap.Error(err))
+		return fmt.Errorf("failed to count token use: %w", err)
+	}
+
+	return nil
+}
+
+// countTokenUseTX counts an accepted join request against the token policy of the locked group.
+// The token was checked when the request was filed, so only the uses are checked again.
+func (s *serviceImpl) countTokenUseTX(tx *gorm.DB, group *model.Group) error {
+	policy := &TokenPolicy{}
+	if err := s.repo.FindTokenPolicyTX(tx, group.ID.String(), policy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		s.log.Named("countTokenUseTX").Error("FindTokenPolicyTX: ", zap.Error(err))
+		return fmt.Errorf("failed to find token policy: %w", err)
+	}
+
+	if err := checkTokenUses(policy); err != nil {
+		s.log.Named("countTokenUseTX").Error("checkTokenUses: ", zap.Error(err))
+		return err
+	}
+
+	if err := s.repo.IncrementTokenUsesTX(tx, group.ID.String()); err != nil {
+		s.log.Named("countTokenUseTX").Error("IncrementTokenUsesTX: ", zap.Error(err))
+		return fmt.Errorf("failed to count token use: %w", err)
+	}
+
+	return nil
+}
+
+func checkTokenPolicy(policy *TokenPolicy) error {
+	if policy.ExpiresAt != nil && !time.Now().Before(*policy.ExpiresAt) {
+		return status.Error(codes.FailedPrecondition, "invite token has expired, ask the group leader for a new one")
+	}
+
+	return checkTokenUses(policy)
+}
+
+func checkTokenUses(policy *TokenPolicy) error {
+	if policy.MaxUses > 0 && policy.Uses >= policy.MaxUses {
+		return status.Error(codes.FailedPrecondition, "invite token has reached its maximum number of uses, ask the group leader for a new one")
+	}
+
+	return nil
+}
+
+// passLeadershipTX makes the longest-standing other member the leader of the locked group.
+// Members who joined before memberships were recorded count as the longest-standing.
+func (s *serviceImpl) passLeadershipTX(tx *gorm.DB, group *model.Group) error {
+	memberships := []Membership{}
+	if err := s.repo.FindMembershipsTX(tx, group.ID.String(), &memberships); err != nil {
+		s.log.Named("passLeadershipTX").Error("FindMembershipsTX: ", zap.Error(err))
+		return fmt.Errorf("failed to find memberships: %w", err)
+	}
+
+	joinedAt := make(map[uuid.UUID]time.Time, len(memberships))
+	for _, m := range memberships {
+		joinedAt[m.UserID] = m.JoinedAt
+	}
+
+	var successor *model.User
 	for _, member := range group.Members {
-		if err := s.cache.SetValue(groupByUserIdKey(member.ID.String()), group, s.conf.CacheTTL); err != nil {
-			return fmt.Errorf("failed to update group cache by user id: %w", err)
+		if member.ID == *group.LeaderID {
+			continue
 		}
+		if successor == nil || joinedAt[member.ID].Before(joinedAt[successor.ID]) {
+			successor = member
+		}
+	}
+	if successor == nil {
+		s.log.Named("passLeadershipTX").Error("Group has no other member", zap.String("group_id", group.ID.String()))
+		return status.Error(codes.PermissionDenied, "You are the only member in the group, so you cannot leave")
+	}
+
+	if err := s.repo.UpdateLeaderTX(tx, group.ID.String(), &successor.ID); err != nil {
+		s.log.Named("passLeadershipTX").Error("UpdateLeaderTX: ", zap.Error(err))
+		return fmt.Errorf("failed to update group leader: %w", err)
+	}
+	oldLeaderId := group.LeaderID
+	group.LeaderID = &successor.ID
+
+	return s.recordEventsTX(tx, &GroupEvent{GroupID: group.ID, Type: constant.GROUP_LEADER_CHANGED, UserID: &successor.ID, ActorID: oldLeaderId})
+}
+
+// resetSelectionsTX clears the group's selections after its members changed and tells the leader through the group history
+func (s *serviceImpl) resetSelectionsTX(tx *gorm.DB, group *model.Group, actorId *uuid.UUID) error {
+	selections := []model.Selection{}
+	if err := s.selectionRepo.FindByGroupIdTX(tx, group.ID.String(), &selections); err != nil {
+		s.log.Named("resetSelectionsTX").Error("FindByGroupIdTX: ", zap.Error(err))
+		return fmt.Errorf("failed to find selections: %w", err)
+	}
+	if len(selections) == 0 {
+		return nil
+	}
+
+	if err := s.selectionRepo.DeleteByGroupIdTX(tx, group.ID.String()); err != nil {
+		s.log.Named("resetSelectionsTX").Error("DeleteByGroupIdTX: ", zap.Error(err))
+		return fmt.Errorf("failed to delete selections: %w", err)
+	}
+
+	return s.recordEventsTX(tx, &GroupEvent{GroupID: group.ID, Type: constant.GROUP_SELECTIONS_RESET, UserID: group.LeaderID, ActorID: actorId})
+}
+
+// carrySelectionsTX copies the selections of a group about to be deleted to the group its member joined,
+// unless the joined group already has its own
+func (s *serviceImpl) carrySelectionsTX(tx *gorm.DB, from *model.Group, to *model.Group) error {
+	existing := []model.Selection{}
+	if err := s.selectionRepo.FindByGroupIdTX(tx, to.ID.String(), &existing); err != nil {
+		s.log.Named("carrySelectionsTX").Error("FindByGroupIdTX to: ", zap.Error(err))
+		return fmt.Errorf("failed to find selections: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	carried := []model.Selection{}
+	if err := s.selectionRepo.FindByGroupIdTX(tx, from.ID.String(), &carried); err != nil {
+		s.log.Named("carrySelectionsTX").Error("FindByGroupIdTX from: ", zap.Error(err))
+		return fmt.Errorf("failed to find selections: %w", err)
+	}
+	if len(carried) == 0 {
+		return nil
+	}
+
+	selections := make([]*model.Selection, len(carried))
+	for i, selection := range carried {
+		selections[i] = &model.Selection{GroupID: &to.ID, Baan: selection.Baan, Order: selection.Order}
 	}
 
-	if err := s.cache.SetValue(groupByTokenKey(group.Token), group, s.conf.CacheTTL); err != nil {
-		return fmt.Errorf("failed to update group cache by token: %w", err)
+	if err := s.selectionRepo.ReplaceByGroupIdTX(tx, to.ID.String(), selections); err != nil {
+		s.log.Named("carrySelectionsTX").Error("ReplaceByGroupIdTX: ", zap.Error(err))
+		return fmt.Errorf("failed to carry selections: %w", err)
 	}
 
 	return nil
 }
 
-func groupByUserIdKey(key string) string {
-	return fmt.Sprintf("groupByUserId:%s", key)
+// assignGroupTX moves the user into the group and records when they joined it
+func (s *serviceImpl) assignGroupTX(tx *gorm.DB, userId uuid.UUID, groupId *uuid.UUID) error {
+	if err := s.userRepo.AssignGroupTX(tx, userId.String(), groupId); err != nil {
+		return err
+	}
+
+	membership := &Membership{
+		UserID:   userId,
+		GroupID:  *groupId,
+		JoinedAt: time.Now(),
+	}
+	if err := s.repo.SaveMembershipTX(tx, membership); err != nil {
+		return fmt.Errorf("failed to save membership: %w", err)
+	}
+
+	return nil
 }
 
-func groupByTokenKey(key string) string {
-	return fmt.Sprintf("groupByToken:%s", key)
+// findGroupForUpdateTX locks the user and then the group they are in. Every mutation takes its
+// locks in this order so that concurrent requests wait for each other instead of deadlocking.
+func (s *serviceImpl) findGroupForUpdateTX(tx *gorm.DB, userId string, group *model.Group) error {
+	user := &model.User{}
+	if err := s.userRepo.FindOneForUpdateTX(tx, userId, user); err != nil {
+		s.log.Named("findGroupForUpdateTX").Error("FindOneForUpdateTX user: ", zap.Error(err))
+		return status.Error(codes.Internal, "failed to find user")
+	}
+
+	if user.GroupID == nil {
+		s.log.Named("findGroupForUpdateTX").Error("User has no group", zap.String("user_id", userId))
+		return status.Error(codes.Internal, "failed to find group")
+	}
+
+	if err := s.repo.FindOneForUpdateTX(tx, user.GroupID.String(), group); err != nil {
+		s.log.Named("findGroupForUpdateTX").Error("FindOneForUpdateTX group: ", zap.Error(err))
+		return status.Error(codes.Internal, "failed to find group")
+	}
+
+	return nil
+}
+
+func isMember(group *model.Group, userId string) bool {
+	for _, member := range group.Members {
+		if member.ID.String() == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LockAll confirms every group so the allocation input is frozen once the selection window closes.
+// It runs once, recorded in the window state, and not while staff override the window.
+func (s *serviceImpl) LockAll(ctx context.Context) error {
+	var locked []uuid.UUID
+	skip := ""
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		state := &WindowState{}
+		if err := s.repo.FindWindowStateForUpdateTX(tx, state); err != nil {
+			s.log.Named("LockAll").Error("FindWindowStateForUpdateTX: ", zap.Error(err))
+			return fmt.Errorf("failed to find window state: %w", err)
+		}
+		if state.LockedAt != nil {
+			skip = "already locked"
+			return nil
+		}
+		if state.Override {
+			skip = "window overridden"
+			return nil
+		}
+
+		var err error
+		locked, err = s.repo.ConfirmAllTX(tx)
+		if err != nil {
+			s.log.Named("LockAll").Error("ConfirmAllTX: ", zap.Error(err))
+			return fmt.Errorf("failed to confirm groups: %w", err)
+		}
+
+		events := make([]*GroupEvent, len(locked))
+		for i, id := range locked {
+			if err := s.snapshotTX(tx, id, true); err != nil {
+				return err
+			}
+			events[i] = &GroupEvent{GroupID: id, Type: constant.GROUP_CONFIRMED}
+		}
+
+		now := time.Now()
+		state.LockedAt = &now
+		if err := s.repo.SaveWindowStateTX(tx, state); err != nil {
+			s.log.Named("LockAll").Error("SaveWindowStateTX: ", zap.Error(err))
+			return fmt.Errorf("failed to save window state: %w", err)
+		}
+
+		return s.recordEventsTX(tx, events...)
+	})
+
+	if err != nil {
+		s.log.Named("LockAll").Error("WithTransaction: ", zap.Error(err))
+		return status.Error(codes.Internal, "failed to lock groups")
+	}
+
+	if skip != "" {
+		s.log.Named("LockAll").Info("Groups not locked", zap.String("reason", skip))
+		return nil
+	}
+
+	if err := s.cache.InvalidatePrefix(ctx, GroupByUserIdKey("")); err != nil {
+		s.log.Named("LockAll").Error("InvalidatePrefix groupByUserId: ", zap.Error(err))
+		return status.Error(codes.Internal, "failed to clear group cache")
+	}
+	if err := s.cache.InvalidatePrefix(ctx, GroupByTokenKey("")); err != nil {
+		s.log.Named("LockAll").Error("InvalidatePrefix groupByToken: ", zap.Error(err))
+		return status.Error(codes.Internal, "failed to clear group cache")
+	}
+
+	s.log.Named("LockAll").Info("Groups locked", zap.Int("count", len(locked)))
+
+	return nil
+}
+
+// snapshotTX freezes the group for allocation when it is confirmed and supersedes the snapshot when it is unconfirmed
+func (s *serviceImpl) snapshotTX(tx *gorm.DB, groupId uuid.UUID, isConfirmed bool) error {
+	if !isConfirmed {
+		if err := s.repo.SupersedeSnapshotTX(tx, groupId.String()); err != nil {
+			s.log.Named("snapshotTX").Error("SupersedeSnapshotTX: ", zap.Error(err))
+			return fmt.Errorf("failed to supersede snapshot: %w", err)
+		}
+		return nil
+	}
+
+	if err := s.repo.CreateSnapshotTX(tx, groupId.String()); err != nil {
+		s.log.Named("snapshotTX").Error("CreateSnapshotTX: ", zap.Error(err))
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// recordEventsTX appends events to the group history in the same transaction as the change they describe
+func (s *serviceImpl) recordEventsTX(tx *gorm.DB, events ...*GroupEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.repo.CreateEventsTX(tx, events); err != nil {
+		s.log.Named("recordEventsTX").Error("CreateEventsTX: ", zap.Error(err))
+		return fmt.Errorf("failed to record group events: %w", err)
+	}
+
+	return nil
+}
+
+// invalidateGroupCache drops the cached views of the groups for all their members and tokens, the
+// next read of each goes to the database. It runs after the change committed, so callers log a
+// failure and still succeed, the entries left behind expire with the cache ttl.
+func (s *serviceImpl) invalidateGroupCache(ctx context.Context, groups ...*model.Group) error {
+	var keys []string
+	for _, group := range groups {
+		keys = append(keys, CacheKeys(group)...)
+	}
+
+	return s.cache.Invalidate(ctx, keys...)
 }
 
 func (s *serviceImpl) checkGroup(group *model.Group) error {
@@ -440,8 +1433,8 @@ func (s *serviceImpl) checkGroup(group *model.Group) error {
 	if len(group.Members) == 0 {
 		return fmt.Errorf("group has no members")
 	}
-	if len(group.Members) > s.conf.Capacity {
-		return fmt.Errorf("group has more than %v members (capacity exceeded)", s.conf.Capacity)
+	if capacity := Capacity(s.conf, group.Members); len(group.Members) > capacity {
+		return fmt.Errorf("group has more than %v members (capacity exceeded)", capacity)
 	}
 
 	return nil
@@ -1,6 +1,11 @@
 package group
 
 import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
 	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
 	"github.com/isd-sgcu/rpkm67-model/model"
 )
@@ -25,6 +30,38 @@ func ModelToProto(group *model.Group) *proto.Group {
 	}
 }
 
+// Capacity is the largest group the members may form. A cohort rule only applies when every member
+// is in a matching cohort, the smallest capacity among the members wins.
+func Capacity(conf *config.GroupConfig, members []*model.User) int {
+	capacity := 0
+	for _, member := range members {
+		memberCapacity := userCapacity(conf, member)
+		if capacity == 0 || memberCapacity < capacity {
+			capacity = memberCapacity
+		}
+	}
+	if capacity == 0 {
+		return conf.Capacity
+	}
+
+	return capacity
+}
+
+// userCapacity returns the capacity of the first cohort rule matching the user
+func userCapacity(conf *config.GroupConfig, user *model.User) int {
+	for _, cohort := range conf.CohortCapacities {
+		if cohort.Faculty != "" && cohort.Faculty != user.Faculty {
+			continue
+		}
+		if cohort.Year != 0 && cohort.Year != user.Year {
+			continue
+		}
+		return cohort.Capacity
+	}
+
+	return conf.Capacity
+}
+
 func UserToUserInfo(user *model.User) *proto.UserInfo {
 	return &proto.UserInfo{
 		Id:        user.ID.String(),
@@ -33,3 +70,56 @@ func UserToUserInfo(user *model.User) *proto.UserInfo {
 		ImageUrl:  user.PhotoUrl,
 	}
 }
+
+func JoinRequestToDTO(joinRequest *JoinRequest) *dto.JoinRequest {
+	var user *proto.UserInfo
+	if joinRequest.User != nil {
+		user = UserToUserInfo(joinRequest.User)
+	}
+
+	return &dto.JoinRequest{
+		Id:        joinRequest.ID.String(),
+		GroupId:   joinRequest.GroupID.String(),
+		User:      user,
+		CreatedAt: joinRequest.CreatedAt,
+		ExpiresAt: joinRequest.ExpiresAt,
+	}
+}
+
+func EventToDTO(event *GroupEvent) *dto.GroupEvent {
+	return &dto.GroupEvent{
+		Id:             event.ID,
+		GroupId:        event.GroupID.String(),
+		Type:           event.Type.String(),
+		UserId:         optionalId(event.UserID),
+		ActorId:        optionalId(event.ActorID),
+		RelatedGroupId: optionalId(event.RelatedGroupID),
+		CreatedAt:      event.CreatedAt,
+	}
+}
+
+func optionalId(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+
+	return id.String()
+}
+
+func GroupByUserIdKey(key string) string {
+	return fmt.Sprintf("groupByUserId:%s", key)
+}
+
+func GroupByTokenKey(key string) string {
+	return fmt.Sprintf("groupByToken:%s", key)
+}
+
+// CacheKeys returns the keys the group is cached under, one per member and one for its token
+func CacheKeys(group *model.Group) []string {
+	keys := make([]string, 0, len(group.Members)+1)
+	for _, member := range group.Members {
+		keys = append(keys, GroupByUserIdKey(member.ID.String()))
+	}
+
+	return append(keys, GroupByTokenKey(group.Token))
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	service "github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	mock_group "github.com/isd-sgcu/rpkm67-backend/mocks/group"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// GroupRPCTestSuite calls the group JSON service through an in-process grpc server
+type GroupRPCTestSuite struct {
+	suite.Suite
+	ctrl        *gomock.Controller
+	mockService *mock_group.MockService
+	server      *grpc.Server
+	conn        *grpc.ClientConn
+	methods     []string // seen by the interceptor
+}
+
+func TestGroupRPCTestSuite(t *testing.T) {
+	suite.Run(t, new(GroupRPCTestSuite))
+}
+
+func (s *GroupRPCTestSuite) SetupTest() {
+	encoding.RegisterCodec(utils.JSONCodec{})
+	s.ctrl = gomock.NewController(s.T())
+	s.mockService = mock_group.NewMockService(s.ctrl)
+	s.methods = nil
+
+	listener := bufconn.Listen(1 << 20)
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		s.methods = append(s.methods, info.FullMethod)
+		return handler(ctx, req)
+	}))
+	service.RegisterGroupJSONServiceServer(s.server, s.mockService)
+	go s.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+	)
+	s.Require().NoError(err)
+	s.conn = conn
+}
+
+func (s *GroupRPCTestSuite) TearDownTest() {
+	s.conn.Close()
+	s.server.Stop()
+	s.ctrl.Finish()
+}
+
+func (s *GroupRPCTestSuite) TestAcceptJoinRequest() {
+	in := &dto.AcceptJoinRequestGroupRequest{LeaderId: "leader-1", RequestId: "request-1"}
+	s.mockService.EXPECT().AcceptJoinRequest(gomock.Any(), in).Return(&dto.AcceptJoinRequestGroupResponse{}, nil)
+
+	out := &dto.AcceptJoinRequestGroupResponse{}
+	err := s.conn.Invoke(context.Background(), constant.GroupJSONService_AcceptJoinRequest_FullMethodName, in, out)
+
+	s.NoError(err)
+	s.Equal([]string{constant.GroupJSONService_AcceptJoinRequest_FullMethodName}, s.methods)
+}
+
+func (s *GroupRPCTestSuite) TestRotateTokenOptions() {
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	s.mockService.EXPECT().RotateToken(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, in *dto.RotateTokenGroupRequest) (*dto.RotateTokenGroupResponse, error) {
+		return &dto.RotateTokenGroupResponse{ExpiresAt: in.ExpiresAt, MaxUses: in.MaxUses}, nil
+	})
+
+	out := &dto.RotateTokenGroupResponse{}
+	err := s.conn.Invoke(context.Background(), constant.GroupJSONService_RotateToken_FullMethodName, &dto.RotateTokenGroupRequest{LeaderId: "leader-1", ExpiresAt: &expiresAt, MaxUses: 3}, out)
+
+	s.NoError(err)
+	s.Equal(expiresAt, *out.ExpiresAt)
+	s.Equal(3, out.MaxUses)
+}
+
+func (s *GroupRPCTestSuite) TestListJoinRequestsError() {
+	s.mockService.EXPECT().ListJoinRequests(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.PermissionDenied, "not the leader"))
+
+	out := &dto.ListJoinRequestsGroupResponse{}
+	err := s.conn.Invoke(context.Background(), constant.GroupJSONService_ListJoinRequests_FullMethodName, &dto.ListJoinRequestsGroupRequest{LeaderId: "leader-1"}, out)
+
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
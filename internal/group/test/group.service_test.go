@@ -0,0 +1,748 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	service "github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"github.com/isd-sgcu/rpkm67-backend/internal/window"
+	mock_cache "github.com/isd-sgcu/rpkm67-backend/mocks/cache"
+	mock_group "github.com/isd-sgcu/rpkm67-backend/mocks/group"
+	mock_user "github.com/isd-sgcu/rpkm67-backend/mocks/user"
+	mock_window "github.com/isd-sgcu/rpkm67-backend/mocks/window"
+	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
+	"github.com/isd-sgcu/rpkm67-model/model"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type GroupServiceTestSuite struct {
+	suite.Suite
+	ctrl         *gomock.Controller
+	mockRepo     *mock_group.MockRepository
+	mockUserRepo *mock_user.MockRepository
+	mockSelRepo  *mock_group.MockSelectionRepository
+	mockCache    *mock_cache.MockAside
+	mockWindow   *mock_window.MockWindow
+	service      service.Service
+	ctx          context.Context
+	config       *config.GroupConfig
+	leader       *model.User
+	member       *model.User
+	joiner       *model.User // leads their own solo group
+	group        *model.Group
+	events       []*service.GroupEvent // recorded by CreateEventsTX
+}
+
+func TestGroupServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(GroupServiceTestSuite))
+}
+
+func (s *GroupServiceTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockRepo = mock_group.NewMockRepository(s.ctrl)
+	s.mockUserRepo = mock_user.NewMockRepository(s.ctrl)
+	s.mockSelRepo = mock_group.NewMockSelectionRepository(s.ctrl)
+	s.mockCache = mock_cache.NewMockAside(s.ctrl)
+	s.mockWindow = mock_window.NewMockWindow(s.ctrl)
+	s.mockWindow.EXPECT().Check().Return(nil).AnyTimes()
+	s.mockRepo.EXPECT().WithTransaction(gomock.Any()).DoAndReturn(func(txFunc func(*gorm.DB) error) error {
+		return txFunc(nil)
+	}).AnyTimes()
+	s.events = nil
+	s.mockRepo.EXPECT().CreateEventsTX(gomock.Any(), gomock.Any()).DoAndReturn(func(_ *gorm.DB, events []*service.GroupEvent) error {
+		s.events = append(s.events, events...)
+		return nil
+	}).AnyTimes()
+	s.config = &config.GroupConfig{Capacity: 3, CacheTTL: 3600}
+	s.service = service.NewService(s.mockRepo, s.mockUserRepo, s.mockSelRepo, s.mockCache, s.mockWindow, s.config, zap.NewNop())
+	s.ctx = context.Background()
+
+	groupId := uuid.New()
+	s.leader = &model.User{Base: model.Base{ID: uuid.New()}, GroupID: &groupId}
+	s.member = &model.User{Base: model.Base{ID: uuid.New()}, GroupID: &groupId}
+	soloGroupId := uuid.New()
+	s.joiner = &model.User{Base: model.Base{ID: uuid.New()}, GroupID: &soloGroupId}
+	s.group = &model.Group{
+		Base:     model.Base{ID: groupId},
+		LeaderID: &s.leader.ID,
+		Token:    "oldtoken",
+		Members:  []*model.User{s.leader, s.member},
+	}
+}
+
+// as returns a context authenticated as the given user
+func (s *GroupServiceTestSuite) as(user *model.User) context.Context {
+	return auth.WithCaller(s.ctx, &auth.Caller{UserId: user.ID.String(), Role: constant.USER})
+}
+
+func (s *GroupServiceTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+func (s *GroupServiceTestSuite) TestRotateToken_Success() {
+	expiresAt := time.Now().Add(time.Hour)
+
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().UpdateTokenTX(gomock.Any(), s.group.ID.String(), gomock.Not("oldtoken")).Return(nil)
+	s.mockRepo.EXPECT().SaveTokenPolicyTX(gomock.Any(), &service.TokenPolicy{GroupID: s.group.ID, ExpiresAt: &expiresAt, MaxUses: 5}).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), "groupByToken:oldtoken").Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	res, err := s.service.RotateToken(s.as(s.leader), &dto.RotateTokenGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		ExpiresAt: &expiresAt,
+		MaxUses:   5,
+	})
+
+	s.NoError(err)
+	s.NotEqual("oldtoken", res.Group.Token)
+	s.NotEmpty(res.Group.Token)
+	s.Equal(&expiresAt, res.ExpiresAt)
+	s.Equal(5, res.MaxUses)
+}
+
+func (s *GroupServiceTestSuite) TestTransferLeadership_InvalidateFailedStillSucceeds() {
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().UpdateLeaderTX(gomock.Any(), s.group.ID.String(), &s.member.ID).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(errors.New("error"))
+
+	res, err := s.service.TransferLeadership(s.as(s.leader), &dto.TransferLeadershipGroupRequest{
+		LeaderId:    s.leader.ID.String(),
+		NewLeaderId: s.member.ID.String(),
+	})
+
+	// the change committed before the cache failed
+	s.NoError(err)
+	s.Equal(s.member.ID.String(), res.Group.LeaderID)
+}
+
+func (s *GroupServiceTestSuite) TestRotateToken_NotLeader() {
+	s.mockCache.EXPECT().Load(gomock.Any(), "groupByUserId:"+s.member.ID.String(), gomock.Any(), s.config.CacheTTL, gomock.Any()).Return(nil)
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.member.ID.String(), gomock.Any()).SetArg(2, *s.member).Return(nil)
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+
+	res, err := s.service.RotateToken(s.as(s.member), &dto.RotateTokenGroupRequest{LeaderId: s.member.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestRotateToken_ExpiresInPast() {
+	expiresAt := time.Now().Add(-time.Minute)
+
+	res, err := s.service.RotateToken(s.as(s.leader), &dto.RotateTokenGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		ExpiresAt: &expiresAt,
+	})
+
+	s.Nil(res)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestTransferLeadership_Success() {
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().UpdateLeaderTX(gomock.Any(), s.group.ID.String(), &s.member.ID).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), "groupByUserId:"+s.leader.ID.String(), "groupByUserId:"+s.member.ID.String(), "groupByToken:oldtoken").Return(nil)
+
+	res, err := s.service.TransferLeadership(s.as(s.leader), &dto.TransferLeadershipGroupRequest{
+		LeaderId:    s.leader.ID.String(),
+		NewLeaderId: s.member.ID.String(),
+	})
+
+	s.NoError(err)
+	s.Equal(s.member.ID.String(), res.Group.LeaderID)
+	s.Require().Len(s.events, 1)
+	s.Equal(constant.GROUP_LEADER_CHANGED, s.events[0].Type)
+	s.Equal(&s.member.ID, s.events[0].UserID)
+	s.Equal(&s.leader.ID, s.events[0].ActorID)
+}
+
+func (s *GroupServiceTestSuite) TestTransferLeadership_Confirmed() {
+	s.group.IsConfirmed = true
+	s.expectLeaderGroup()
+
+	res, err := s.service.TransferLeadership(s.as(s.leader), &dto.TransferLeadershipGroupRequest{
+		LeaderId:    s.leader.ID.String(),
+		NewLeaderId: s.member.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+	s.Empty(s.events)
+}
+
+func (s *GroupServiceTestSuite) TestTransferLeadership_WindowClosed() {
+	closed := window.NewWindow(&config.SelectionConfig{CloseAt: time.Now().Add(-time.Minute)})
+	svc := service.NewService(s.mockRepo, s.mockUserRepo, s.mockSelRepo, s.mockCache, closed, s.config, zap.NewNop())
+
+	res, err := svc.TransferLeadership(s.as(s.leader), &dto.TransferLeadershipGroupRequest{
+		LeaderId:    s.leader.ID.String(),
+		NewLeaderId: s.member.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestTransferLeadership_NotMember() {
+	s.expectLeaderGroup()
+
+	res, err := s.service.TransferLeadership(s.as(s.leader), &dto.TransferLeadershipGroupRequest{
+		LeaderId:    s.leader.ID.String(),
+		NewLeaderId: s.joiner.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestTransferLeadership_Self() {
+	res, err := s.service.TransferLeadership(s.as(s.leader), &dto.TransferLeadershipGroupRequest{
+		LeaderId:    s.leader.ID.String(),
+		NewLeaderId: s.leader.ID.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestLeave_LeaderWithoutSuccession() {
+	s.expectLeaderGroup()
+
+	res, err := s.service.Leave(s.as(s.leader), &proto.LeaveGroupRequest{UserId: s.leader.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestLeave_OtherCaller() {
+	res, err := s.service.Leave(s.as(s.member), &proto.LeaveGroupRequest{UserId: s.leader.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestUpdateConfirm_OtherCaller() {
+	res, err := s.service.UpdateConfirm(s.as(s.member), &proto.UpdateConfirmGroupRequest{LeaderId: s.leader.ID.String(), IsConfirmed: true})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestUpdateConfirm_BelowMinSize() {
+	s.config.MinSize = 3
+	s.expectLeaderGroup()
+
+	res, err := s.service.UpdateConfirm(s.as(s.leader), &proto.UpdateConfirmGroupRequest{LeaderId: s.leader.ID.String(), IsConfirmed: true})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+	s.Empty(s.events)
+}
+
+func (s *GroupServiceTestSuite) TestUpdateConfirm_CreatesSnapshot() {
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().UpdateConfirmTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().CreateSnapshotTX(gomock.Any(), s.group.ID.String()).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	res, err := s.service.UpdateConfirm(s.as(s.leader), &proto.UpdateConfirmGroupRequest{LeaderId: s.leader.ID.String(), IsConfirmed: true})
+
+	s.NoError(err)
+	s.True(res.Group.IsConfirmed)
+	s.Equal([]constant.GroupEventType{constant.GROUP_CONFIRMED}, eventTypes(s.events))
+}
+
+func (s *GroupServiceTestSuite) TestLockAll_CreatesSnapshots() {
+	locked := []uuid.UUID{uuid.New(), uuid.New()}
+	s.mockRepo.EXPECT().FindWindowStateForUpdateTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().ConfirmAllTX(gomock.Any()).Return(locked, nil)
+	s.mockRepo.EXPECT().CreateSnapshotTX(gomock.Any(), locked[0].String()).Return(nil)
+	s.mockRepo.EXPECT().CreateSnapshotTX(gomock.Any(), locked[1].String()).Return(nil)
+	s.mockRepo.EXPECT().SaveWindowStateTX(gomock.Any(), gomock.Any()).DoAndReturn(func(_ *gorm.DB, state *service.WindowState) error {
+		s.NotNil(state.LockedAt)
+		return nil
+	})
+	s.mockCache.EXPECT().InvalidatePrefix(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	err := s.service.LockAll(s.ctx)
+
+	s.NoError(err)
+	s.Len(s.events, 2)
+}
+
+func (s *GroupServiceTestSuite) TestLockAll_AlreadyLocked() {
+	lockedAt := time.Now().Add(-time.Hour)
+	s.mockRepo.EXPECT().FindWindowStateForUpdateTX(gomock.Any(), gomock.Any()).SetArg(1, service.WindowState{LockedAt: &lockedAt}).Return(nil)
+
+	err := s.service.LockAll(s.ctx)
+
+	s.NoError(err)
+	s.Empty(s.events)
+}
+
+func (s *GroupServiceTestSuite) TestLockAll_Overridden() {
+	s.mockRepo.EXPECT().FindWindowStateForUpdateTX(gomock.Any(), gomock.Any()).SetArg(1, service.WindowState{Override: true}).Return(nil)
+
+	err := s.service.LockAll(s.ctx)
+
+	s.NoError(err)
+	s.Empty(s.events)
+}
+
+func (s *GroupServiceTestSuite) TestUpdateConfirm_UnconfirmBelowMinSize() {
+	s.config.MinSize = 3
+	s.group.IsConfirmed = true
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().UpdateConfirmTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().SupersedeSnapshotTX(gomock.Any(), s.group.ID.String()).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	res, err := s.service.UpdateConfirm(s.as(s.leader), &proto.UpdateConfirmGroupRequest{LeaderId: s.leader.ID.String(), IsConfirmed: false})
+
+	s.NoError(err)
+	s.False(res.Group.IsConfirmed)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_FullGroup() {
+	s.config.Capacity = 2
+
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(gorm.ErrRecordNotFound)
+
+	res, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestJoin_CohortCapacity() {
+	s.config.Capacity = 2
+	s.config.CohortCapacities = []config.CohortCapacity{{Faculty: "21", Capacity: 3}}
+	s.leader.Faculty, s.member.Faculty, s.joiner.Faculty = "21", "21", "21"
+
+	s.expectJoin()
+
+	res, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.NoError(err)
+	s.Len(res.Group.Members, 3)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_NoCaller() {
+	res, err := s.service.Join(s.ctx, &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestLeave_LeaderSuccession() {
+	s.config.LeaderSuccession = true
+	third := &model.User{Base: model.Base{ID: uuid.New()}, GroupID: &s.group.ID}
+	s.group.Members = append(s.group.Members, third)
+	now := time.Now()
+
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().FindMembershipsTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, []service.Membership{
+		{UserID: s.leader.ID, GroupID: s.group.ID, JoinedAt: now.Add(-3 * time.Hour)},
+		{UserID: s.member.ID, GroupID: s.group.ID, JoinedAt: now.Add(-time.Hour)},
+		{UserID: third.ID, GroupID: s.group.ID, JoinedAt: now.Add(-2 * time.Hour)},
+	}).Return(nil)
+	s.mockRepo.EXPECT().UpdateLeaderTX(gomock.Any(), s.group.ID.String(), &third.ID).Return(nil)
+	s.mockRepo.EXPECT().CreateTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), s.leader.ID.String(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+
+	soloGroupId := uuid.New()
+	soloGroup := model.Group{Base: model.Base{ID: soloGroupId}, LeaderID: &s.leader.ID, Token: "solotoken", Members: []*model.User{s.leader}}
+	updatedGroup := model.Group{Base: model.Base{ID: s.group.ID}, LeaderID: &third.ID, Token: "oldtoken", Members: []*model.User{s.member, third}}
+	s.mockUserRepo.EXPECT().FindOne(s.leader.ID.String(), gomock.Any()).SetArg(1, model.User{Base: s.leader.Base, GroupID: &soloGroupId}).Return(nil)
+	s.mockRepo.EXPECT().FindOne(soloGroupId.String(), gomock.Any()).SetArg(1, soloGroup).Return(nil)
+	s.mockRepo.EXPECT().FindOne(s.group.ID.String(), gomock.Any()).SetArg(1, updatedGroup).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	res, err := s.service.Leave(s.as(s.leader), &proto.LeaveGroupRequest{UserId: s.leader.ID.String()})
+
+	s.NoError(err)
+	s.Equal(third.ID.String(), res.Group.LeaderID)
+	s.Equal([]constant.GroupEventType{constant.GROUP_LEADER_CHANGED, constant.GROUP_LEFT, constant.GROUP_CREATED}, eventTypes(s.events))
+}
+
+func (s *GroupServiceTestSuite) TestFindByToken_Expired() {
+	expiresAt := time.Now().Add(-time.Minute)
+
+	s.mockCache.EXPECT().Load(gomock.Any(), "groupByToken:oldtoken", gomock.Any(), s.config.CacheTTL, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ interface{}, _ int, load func() error) error { return load() })
+	s.mockRepo.EXPECT().FindByToken("oldtoken", gomock.Any()).SetArg(1, *s.group).Return(nil)
+	s.mockRepo.EXPECT().FindTokenPolicy(s.group.ID.String(), gomock.Any()).SetArg(1, service.TokenPolicy{GroupID: s.group.ID, ExpiresAt: &expiresAt}).Return(nil)
+
+	res, err := s.service.FindByToken(s.ctx, &proto.FindByTokenGroupRequest{Token: "oldtoken"})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestJoin_TokenExpired() {
+	expiresAt := time.Now().Add(-time.Minute)
+
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, service.TokenPolicy{GroupID: s.group.ID, ExpiresAt: &expiresAt}).Return(nil)
+
+	res, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestJoin_TokenMaxUsesReached() {
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, service.TokenPolicy{GroupID: s.group.ID, MaxUses: 2, Uses: 2}).Return(nil)
+
+	res, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestJoin_TokenUseCounted() {
+	joiner := s.joiner
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, service.TokenPolicy{GroupID: s.group.ID, MaxUses: 2, Uses: 1}).Return(nil)
+	s.mockRepo.EXPECT().IncrementTokenUsesTX(gomock.Any(), s.group.ID.String()).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), joiner.ID.String(), &s.group.ID).Return(nil)
+	s.mockRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), joiner.ID.String()).Return(nil)
+	s.mockRepo.EXPECT().DeleteGroupTX(gomock.Any(), joiner.GroupID).Return(nil)
+
+	joinedGroup := *s.group
+	joinedGroup.Members = append(joinedGroup.Members, joiner)
+	s.mockRepo.EXPECT().FindOne(s.group.ID.String(), gomock.Any()).SetArg(1, joinedGroup).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	res, err := s.service.Join(s.as(joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: joiner.ID.String()})
+
+	s.NoError(err)
+	s.Len(res.Group.Members, 3)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_KeepCarriesSelections() {
+	s.config.SelectionPolicy = constant.SELECTION_KEEP
+	s.expectJoin()
+	s.mockSelRepo.EXPECT().FindByGroupIdTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(nil)
+	s.mockSelRepo.EXPECT().FindByGroupIdTX(gomock.Any(), s.joiner.GroupID.String(), gomock.Any()).SetArg(2, []model.Selection{
+		{GroupID: s.joiner.GroupID, Baan: "baan-1", Order: 1},
+		{GroupID: s.joiner.GroupID, Baan: "baan-2", Order: 2},
+	}).Return(nil)
+	s.mockSelRepo.EXPECT().ReplaceByGroupIdTX(gomock.Any(), s.group.ID.String(), []*model.Selection{
+		{GroupID: &s.group.ID, Baan: "baan-1", Order: 1},
+		{GroupID: &s.group.ID, Baan: "baan-2", Order: 2},
+	}).Return(nil)
+
+	_, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.NoError(err)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_KeepJoinedGroupRankings() {
+	s.config.SelectionPolicy = constant.SELECTION_KEEP
+	s.expectJoin()
+	s.mockSelRepo.EXPECT().FindByGroupIdTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, []model.Selection{
+		{GroupID: &s.group.ID, Baan: "baan-3", Order: 1},
+	}).Return(nil)
+
+	_, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.NoError(err)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_ResetClearsSelections() {
+	s.config.SelectionPolicy = constant.SELECTION_RESET
+	s.expectJoin()
+	s.mockSelRepo.EXPECT().FindByGroupIdTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, []model.Selection{
+		{GroupID: &s.group.ID, Baan: "baan-3", Order: 1},
+	}).Return(nil)
+	s.mockSelRepo.EXPECT().DeleteByGroupIdTX(gomock.Any(), s.group.ID.String()).Return(nil)
+
+	_, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.NoError(err)
+	s.Equal([]constant.GroupEventType{constant.GROUP_LEFT, constant.GROUP_JOINED, constant.GROUP_SELECTIONS_RESET}, eventTypes(s.events))
+	s.Equal(s.leader.ID, *s.events[2].UserID)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_ApprovalCreatesRequest() {
+	s.config.JoinApproval = true
+	s.config.JoinRequestTTL = 60
+
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(gorm.ErrRecordNotFound)
+	s.mockRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), s.joiner.ID.String()).Return(nil)
+	s.mockRepo.EXPECT().CreateJoinRequestTX(gomock.Any(), gomock.Any()).DoAndReturn(func(_ *gorm.DB, joinRequest *service.JoinRequest) error {
+		s.Equal(s.group.ID, joinRequest.GroupID)
+		s.Equal(s.joiner.ID, joinRequest.UserID)
+		s.WithinDuration(time.Now().Add(time.Minute), *joinRequest.ExpiresAt, time.Second)
+		return nil
+	})
+
+	res, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.NoError(err)
+	s.Equal(s.joiner.GroupID.String(), res.Group.Id)
+}
+
+func (s *GroupServiceTestSuite) TestJoin_ApprovalDoesNotCountTokenUse() {
+	s.config.JoinApproval = true
+
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, service.TokenPolicy{GroupID: s.group.ID, MaxUses: 2, Uses: 1}).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), s.joiner.ID.String()).Return(nil)
+	s.mockRepo.EXPECT().CreateJoinRequestTX(gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := s.service.Join(s.as(s.joiner), &proto.JoinGroupRequest{Token: "oldtoken", UserId: s.joiner.ID.String()})
+
+	s.NoError(err)
+}
+
+func (s *GroupServiceTestSuite) TestAcceptJoinRequest_Success() {
+	requestId := uuid.New()
+	s.mockRepo.EXPECT().FindJoinRequest(requestId.String(), gomock.Any()).SetArg(1, service.JoinRequest{ID: requestId, GroupID: s.group.ID, UserID: s.joiner.ID}).Return(nil)
+	s.expectPrevGroup()
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestTX(gomock.Any(), requestId.String()).Return(nil)
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(gorm.ErrRecordNotFound)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), s.joiner.ID.String(), &s.group.ID).Return(nil)
+	s.mockRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), s.joiner.ID.String()).Return(nil)
+	s.mockRepo.EXPECT().DeleteGroupTX(gomock.Any(), s.joiner.GroupID).Return(nil)
+
+	joinedGroup := *s.group
+	joinedGroup.Members = append(joinedGroup.Members, s.joiner)
+	s.mockRepo.EXPECT().FindOne(s.group.ID.String(), gomock.Any()).SetArg(1, joinedGroup).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	res, err := s.service.AcceptJoinRequest(s.as(s.leader), &dto.AcceptJoinRequestGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		RequestId: requestId.String(),
+	})
+
+	s.NoError(err)
+	s.Len(res.Group.Members, 3)
+	s.Equal([]constant.GroupEventType{constant.GROUP_LEFT, constant.GROUP_JOINED}, eventTypes(s.events))
+	s.Equal(&s.leader.ID, s.events[1].ActorID)
+}
+
+func (s *GroupServiceTestSuite) TestAcceptJoinRequest_Expired() {
+	requestId := uuid.New()
+	expiresAt := time.Now().Add(-time.Minute)
+	s.mockRepo.EXPECT().FindJoinRequest(requestId.String(), gomock.Any()).SetArg(1, service.JoinRequest{ID: requestId, GroupID: s.group.ID, UserID: s.joiner.ID, ExpiresAt: &expiresAt}).Return(nil)
+	s.expectPrevGroup()
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestTX(gomock.Any(), requestId.String()).Return(nil)
+
+	res, err := s.service.AcceptJoinRequest(s.as(s.leader), &dto.AcceptJoinRequestGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		RequestId: requestId.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestAcceptJoinRequest_TokenUseCounted() {
+	requestId := uuid.New()
+	expiresAt := time.Now().Add(-time.Minute)
+	s.mockRepo.EXPECT().FindJoinRequest(requestId.String(), gomock.Any()).SetArg(1, service.JoinRequest{ID: requestId, GroupID: s.group.ID, UserID: s.joiner.ID}).Return(nil)
+	s.expectPrevGroup()
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestTX(gomock.Any(), requestId.String()).Return(nil)
+	// the token expired after the request was filed, only its uses are checked again
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, service.TokenPolicy{GroupID: s.group.ID, ExpiresAt: &expiresAt, MaxUses: 2, Uses: 1}).Return(nil)
+	s.mockRepo.EXPECT().IncrementTokenUsesTX(gomock.Any(), s.group.ID.String()).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), s.joiner.ID.String(), &s.group.ID).Return(nil)
+	s.mockRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), s.joiner.ID.String()).Return(nil)
+	s.mockRepo.EXPECT().DeleteGroupTX(gomock.Any(), s.joiner.GroupID).Return(nil)
+	s.mockRepo.EXPECT().FindOne(s.group.ID.String(), gomock.Any()).SetArg(1, *s.group).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := s.service.AcceptJoinRequest(s.as(s.leader), &dto.AcceptJoinRequestGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		RequestId: requestId.String(),
+	})
+
+	s.NoError(err)
+}
+
+func (s *GroupServiceTestSuite) TestAcceptJoinRequest_TokenMaxUsesReached() {
+	requestId := uuid.New()
+	s.mockRepo.EXPECT().FindJoinRequest(requestId.String(), gomock.Any()).SetArg(1, service.JoinRequest{ID: requestId, GroupID: s.group.ID, UserID: s.joiner.ID}).Return(nil)
+	s.expectPrevGroup()
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestTX(gomock.Any(), requestId.String()).Return(nil)
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, service.TokenPolicy{GroupID: s.group.ID, MaxUses: 2, Uses: 2}).Return(nil)
+
+	res, err := s.service.AcceptJoinRequest(s.as(s.leader), &dto.AcceptJoinRequestGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		RequestId: requestId.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestAcceptJoinRequest_NotLeader() {
+	requestId := uuid.New()
+	s.mockRepo.EXPECT().FindJoinRequest(requestId.String(), gomock.Any()).SetArg(1, service.JoinRequest{ID: requestId, GroupID: s.group.ID, UserID: s.joiner.ID}).Return(nil)
+	s.expectPrevGroup()
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+
+	res, err := s.service.AcceptJoinRequest(s.as(s.member), &dto.AcceptJoinRequestGroupRequest{
+		LeaderId:  s.member.ID.String(),
+		RequestId: requestId.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestRejectJoinRequest_OtherGroup() {
+	requestId := uuid.New()
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.leader.ID.String(), gomock.Any()).SetArg(2, *s.leader).Return(nil)
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+	s.mockRepo.EXPECT().FindJoinRequest(requestId.String(), gomock.Any()).SetArg(1, service.JoinRequest{ID: requestId, GroupID: uuid.New(), UserID: s.joiner.ID}).Return(nil)
+
+	res, err := s.service.RejectJoinRequest(s.as(s.leader), &dto.RejectJoinRequestGroupRequest{
+		LeaderId:  s.leader.ID.String(),
+		RequestId: requestId.String(),
+	})
+
+	s.Nil(res)
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestDisband_Success() {
+	s.expectLeaderGroup()
+	s.mockRepo.EXPECT().CreateTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), s.member.ID.String(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockSelRepo.EXPECT().DeleteByGroupIdTX(gomock.Any(), s.group.ID.String()).Return(nil)
+
+	soloGroupId := uuid.New()
+	soloGroup := model.Group{Base: model.Base{ID: soloGroupId}, LeaderID: &s.member.ID, Token: "solotoken", Members: []*model.User{s.member}}
+	updatedGroup := model.Group{Base: model.Base{ID: s.group.ID}, LeaderID: &s.leader.ID, Token: "oldtoken", Members: []*model.User{s.leader}}
+	s.mockUserRepo.EXPECT().FindOne(s.member.ID.String(), gomock.Any()).SetArg(1, model.User{Base: s.member.Base, GroupID: &soloGroupId}).Return(nil)
+	s.mockRepo.EXPECT().FindOne(soloGroupId.String(), gomock.Any()).SetArg(1, soloGroup).Return(nil)
+	s.mockRepo.EXPECT().FindOne(s.group.ID.String(), gomock.Any()).SetArg(1, updatedGroup).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), "groupByUserId:"+s.member.ID.String(), "groupByToken:solotoken").Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(), "groupByUserId:"+s.leader.ID.String(), "groupByToken:oldtoken").Return(nil)
+
+	res, err := s.service.Disband(s.as(s.leader), &dto.DisbandGroupRequest{LeaderId: s.leader.ID.String()})
+
+	s.NoError(err)
+	s.Len(res.Group.Members, 1)
+	s.Equal([]constant.GroupEventType{constant.GROUP_REMOVED, constant.GROUP_CREATED}, eventTypes(s.events))
+}
+
+func (s *GroupServiceTestSuite) TestDisband_NotLeader() {
+	s.mockCache.EXPECT().Load(gomock.Any(), "groupByUserId:"+s.member.ID.String(), gomock.Any(), s.config.CacheTTL, gomock.Any()).Return(nil)
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.member.ID.String(), gomock.Any()).SetArg(2, *s.member).Return(nil)
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+
+	res, err := s.service.Disband(s.as(s.member), &dto.DisbandGroupRequest{LeaderId: s.member.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *GroupServiceTestSuite) TestDisband_Confirmed() {
+	s.group.IsConfirmed = true
+	s.expectLeaderGroup()
+
+	res, err := s.service.Disband(s.as(s.leader), &dto.DisbandGroupRequest{LeaderId: s.leader.ID.String()})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+	s.Empty(s.events)
+}
+
+func (s *GroupServiceTestSuite) expectLeaderGroup() {
+	s.mockCache.EXPECT().Load(gomock.Any(), "groupByUserId:"+s.leader.ID.String(), gomock.Any(), s.config.CacheTTL, gomock.Any()).Return(nil)
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.leader.ID.String(), gomock.Any()).SetArg(2, *s.leader).Return(nil)
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), s.group.ID.String(), gomock.Any()).SetArg(2, *s.group).Return(nil)
+}
+
+// expectJoin expects the joiner to move from their solo group into the group
+func (s *GroupServiceTestSuite) expectJoin() {
+	s.expectJoiningGroup()
+	s.mockRepo.EXPECT().FindTokenPolicyTX(gomock.Any(), s.group.ID.String(), gomock.Any()).Return(gorm.ErrRecordNotFound)
+	s.mockUserRepo.EXPECT().AssignGroupTX(gomock.Any(), s.joiner.ID.String(), &s.group.ID).Return(nil)
+	s.mockRepo.EXPECT().SaveMembershipTX(gomock.Any(), gomock.Any()).Return(nil)
+	s.mockRepo.EXPECT().DeleteJoinRequestsByUserIdTX(gomock.Any(), s.joiner.ID.String()).Return(nil)
+	s.mockRepo.EXPECT().DeleteGroupTX(gomock.Any(), s.joiner.GroupID).Return(nil)
+
+	joinedGroup := *s.group
+	joinedGroup.Members = append(joinedGroup.Members, s.joiner)
+	s.mockRepo.EXPECT().FindOne(s.group.ID.String(), gomock.Any()).SetArg(1, joinedGroup).Return(nil)
+	s.mockCache.EXPECT().Invalidate(gomock.Any(),
+		"groupByUserId:"+s.leader.ID.String(), "groupByUserId:"+s.member.ID.String(), "groupByUserId:"+s.joiner.ID.String(), "groupByToken:oldtoken",
+		"groupByUserId:"+s.joiner.ID.String(), "groupByToken:solotoken",
+	).Return(nil)
+}
+
+func (s *GroupServiceTestSuite) expectJoiningGroup() {
+	s.expectPrevGroup()
+	s.mockRepo.EXPECT().FindByTokenForUpdateTX(gomock.Any(), "oldtoken", gomock.Any()).SetArg(2, *s.group).Return(nil)
+}
+
+// expectPrevGroup expects the joiner and their solo group to be locked
+func (s *GroupServiceTestSuite) expectPrevGroup() {
+	joiner := s.joiner
+	soloGroup := model.Group{
+		Base:     model.Base{ID: *joiner.GroupID},
+		LeaderID: &joiner.ID,
+		Token:    "solotoken",
+		Members:  []*model.User{joiner},
+	}
+
+	s.mockCache.EXPECT().Load(gomock.Any(), "groupByUserId:"+joiner.ID.String(), gomock.Any(), s.config.CacheTTL, gomock.Any()).Return(nil)
+	s.mockUserRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), joiner.ID.String(), gomock.Any()).SetArg(2, *joiner).Return(nil)
+	s.mockRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), joiner.GroupID.String(), gomock.Any()).SetArg(2, soloGroup).Return(nil)
+}
+
+func (s *GroupServiceTestSuite) TestCapacity() {
+	conf := &config.GroupConfig{
+		Capacity: 3,
+		CohortCapacities: []config.CohortCapacity{
+			{Faculty: "21", Year: 1, Capacity: 5},
+			{Year: 6, Capacity: 2},
+		},
+	}
+	freshman := &model.User{Faculty: "21", Year: 1}
+	other := &model.User{Faculty: "22", Year: 1}
+	senior := &model.User{Faculty: "21", Year: 6}
+
+	s.Equal(3, service.Capacity(conf, nil))
+	s.Equal(5, service.Capacity(conf, []*model.User{freshman, freshman}))
+	s.Equal(3, service.Capacity(conf, []*model.User{freshman, other}))
+	s.Equal(2, service.Capacity(conf, []*model.User{freshman, senior}))
+}
+
+func eventTypes(events []*service.GroupEvent) []constant.GroupEventType {
+	types := make([]constant.GroupEventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
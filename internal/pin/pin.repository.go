@@ -6,25 +6,35 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/isd-sgcu/rpkm67-backend/config"
 	"github.com/redis/go-redis/v9"
 )
 
+// Repository methods run under the caller's context, bounded by the configured timeout
 type Repository interface {
-	SetPin(key string, code interface{}) error
-	GetPin(key string, code interface{}) error
-	DeletePin(key string) error
+	SetPin(ctx context.Context, key string, code interface{}) error
+	GetPin(ctx context.Context, key string, code interface{}) error
+	DeletePin(ctx context.Context, key string) error
 }
 
+const defaultTimeout = 5 * time.Second
+
 type repositoryImpl struct {
-	client *redis.Client
+	client  *redis.Client
+	timeout time.Duration
 }
 
-func NewRepository(client *redis.Client) Repository {
-	return &repositoryImpl{client: client}
+func NewRepository(client *redis.Client, conf *config.PinConfig) Repository {
+	timeout := time.Duration(conf.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &repositoryImpl{client: client, timeout: timeout}
 }
 
-func (r *repositoryImpl) SetPin(key string, pin interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *repositoryImpl) SetPin(ctx context.Context, key string, pin interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	v, err := json.Marshal(pin)
@@ -35,8 +45,8 @@ func (r *repositoryImpl) SetPin(key string, pin interface{}) error {
 	return r.client.Set(ctx, pinKey(key), v, 0).Err()
 }
 
-func (r *repositoryImpl) GetPin(key string, pin interface{}) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *repositoryImpl) GetPin(ctx context.Context, key string, pin interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	v, err := r.client.Get(ctx, pinKey(key)).Result()
@@ -47,8 +57,8 @@ func (r *repositoryImpl) GetPin(key string, pin interface{}) error {
 	return json.Unmarshal([]byte(v), pin)
 }
 
-func (r *repositoryImpl) DeletePin(key string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *repositoryImpl) DeletePin(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	return r.client.Del(ctx, pinKey(key)).Err()
@@ -3,6 +3,7 @@ package pin
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/isd-sgcu/rpkm67-backend/config"
 	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
@@ -33,7 +34,7 @@ func NewService(conf *config.PinConfig, utils Utils, repo Repository, log *zap.L
 	}
 }
 
-func (s *serviceImpl) FindAll(_ context.Context, in *proto.FindAllPinRequest) (res *proto.FindAllPinResponse, err error) {
+func (s *serviceImpl) FindAll(ctx context.Context, in *proto.FindAllPinRequest) (res *proto.FindAllPinResponse, err error) {
 	res = &proto.FindAllPinResponse{}
 	keys := []string{}
 
@@ -45,15 +46,21 @@ func (s *serviceImpl) FindAll(_ context.Context, in *proto.FindAllPinRequest) (r
 	}
 
 	for _, key := range keys {
-		pin, err := s.getPin(key)
+		pin, err := s.getPin(ctx, key)
 		if err != nil {
 			s.log.Named("FindAll").Error(fmt.Sprintf("getPin: key=%s", key), zap.Error(err))
 			return nil, status.Error(codes.Internal, err.Error())
 		}
 
+		code, err := s.code(pin, s.window())
+		if err != nil {
+			s.log.Named("FindAll").Error(fmt.Sprintf("code: key=%s", key), zap.Error(err))
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
 		res.Pins = append(res.Pins, &proto.Pin{
 			ActivityId: key,
-			Code:       pin.Code,
+			Code:       code,
 		})
 	}
 
@@ -62,24 +69,30 @@ func (s *serviceImpl) FindAll(_ context.Context, in *proto.FindAllPinRequest) (r
 	}, nil
 }
 
-func (s *serviceImpl) ResetPin(_ context.Context, in *proto.ResetPinRequest) (res *proto.ResetPinResponse, err error) {
-	err = s.repo.DeletePin(in.ActivityId)
+func (s *serviceImpl) ResetPin(ctx context.Context, in *proto.ResetPinRequest) (res *proto.ResetPinResponse, err error) {
+	err = s.repo.DeletePin(ctx, in.ActivityId)
 	if err != nil {
 		s.log.Named("ResetPin").Error("DeletePin: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	code, err := s.utils.GeneratePIN()
+	pin, err := s.generatePin()
 	if err != nil {
-		s.log.Named("ResetPin").Error("generatePIN: ", zap.Error(err))
+		s.log.Named("ResetPin").Error("generatePin: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	err = s.repo.SetPin(in.ActivityId, &dto.Pin{Code: code})
+	err = s.repo.SetPin(ctx, in.ActivityId, pin)
 	if err != nil {
 		s.log.Named("ResetPin").Error("SetPin: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	code, err := s.code(pin, s.window())
+	if err != nil {
+		s.log.Named("ResetPin").Error("code: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
 	return &proto.ResetPinResponse{
 		Pin: &proto.Pin{
 			ActivityId: in.ActivityId,
@@ -88,43 +101,58 @@ func (s *serviceImpl) ResetPin(_ context.Context, in *proto.ResetPinRequest) (re
 	}, nil
 }
 
-func (s *serviceImpl) CheckPin(_ context.Context, in *proto.CheckPinRequest) (*proto.CheckPinResponse, error) {
-	pin, err := s.getPin(in.ActivityId)
+func (s *serviceImpl) CheckPin(ctx context.Context, in *proto.CheckPinRequest) (*proto.CheckPinResponse, error) {
+	pin, err := s.getPin(ctx, in.ActivityId)
 	if err != nil {
 		s.log.Named("CheckPin").Error(fmt.Sprintf("getPin: key=%s", in.ActivityId), zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	if pin.Code != in.Code {
-		return &proto.CheckPinResponse{
-			IsMatch: false,
-		}, nil
+	// a rotating pin read just before it changed is still accepted
+	windows := []int64{s.window()}
+	if s.rotating() {
+		windows = append(windows, windows[0]-1)
+	}
+
+	for _, window := range windows {
+		code, err := s.code(pin, window)
+		if err != nil {
+			s.log.Named("CheckPin").Error(fmt.Sprintf("code: key=%s", in.ActivityId), zap.Error(err))
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		if code == in.Code {
+			return &proto.CheckPinResponse{
+				IsMatch: true,
+			}, nil
+		}
 	}
 
 	return &proto.CheckPinResponse{
-		IsMatch: true,
+		IsMatch: false,
 	}, nil
 }
 
-func (s *serviceImpl) getPin(key string) (*dto.Pin, error) {
+func (s *serviceImpl) getPin(ctx context.Context, key string) (*dto.Pin, error) {
 	pin := &dto.Pin{}
 
-	err := s.repo.GetPin(key, pin)
+	err := s.repo.GetPin(ctx, key, pin)
 	if err != nil {
 		s.log.Named("GetPin").Error(fmt.Sprintf("GetPin: key=%s", key), zap.Error(err))
 		if err.Error() != "redis: nil" {
 			return nil, err
 		}
+	}
 
-		code, err := s.utils.GeneratePIN()
+	// a missing pin, or one stored before switching between static and rotating, is generated
+	if err != nil || (s.rotating() && pin.Secret == "") || (!s.rotating() && pin.Code == "") {
+		pin, err = s.generatePin()
 		if err != nil {
-			s.log.Named("GetPin").Error("generatePIN: ", zap.Error(err))
+			s.log.Named("GetPin").Error("generatePin: ", zap.Error(err))
 			return nil, err
 		}
 
-		pin.Code = code
-
-		err = s.repo.SetPin(key, &dto.Pin{Code: pin.Code})
+		err = s.repo.SetPin(ctx, key, pin)
 		if err != nil {
 			s.log.Named("GetPin").Error(fmt.Sprintf("SetPin: key=%s", key), zap.Error(err))
 			return nil, err
@@ -133,3 +161,43 @@ func (s *serviceImpl) getPin(key string) (*dto.Pin, error) {
 
 	return pin, nil
 }
+
+// generatePin creates a static code, or the secret of a rotating pin
+func (s *serviceImpl) generatePin() (*dto.Pin, error) {
+	if !s.rotating() {
+		code, err := s.utils.GeneratePIN()
+		if err != nil {
+			return nil, err
+		}
+		return &dto.Pin{Code: code}, nil
+	}
+
+	secret, err := s.utils.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.Pin{Secret: secret}, nil
+}
+
+// code is the code of pin in window, static pins have the same code in every window
+func (s *serviceImpl) code(pin *dto.Pin, window int64) (string, error) {
+	if !s.rotating() {
+		return pin.Code, nil
+	}
+
+	return s.utils.TimePIN(pin.Secret, window)
+}
+
+func (s *serviceImpl) rotating() bool {
+	return s.conf.Period > 0
+}
+
+// window numbers the periods since the unix epoch, 0 for static pins
+func (s *serviceImpl) window() int64 {
+	if !s.rotating() {
+		return 0
+	}
+
+	return time.Now().Unix() / int64(s.conf.Period)
+}
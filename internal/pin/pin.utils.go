@@ -1,15 +1,29 @@
 package pin
 
 import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"golang.org/x/exp/rand"
 )
 
 type Utils interface {
 	GeneratePIN() (string, error)
+	// GenerateSecret returns a random base32 secret for rotating pins
+	GenerateSecret() (string, error)
+	// TimePIN derives the 6 digit pin of secret for a time window, as in TOTP (RFC 6238)
+	TimePIN(secret string, window int64) (string, error)
 }
 
+const secretSize = 20
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 type utilsImpl struct{}
 
 func NewUtils() Utils {
@@ -24,3 +38,31 @@ func (u *utilsImpl) GeneratePIN() (string, error) {
 
 	return fmt.Sprint(pin), nil
 }
+
+func (u *utilsImpl) GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := crand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return secretEncoding.EncodeToString(secret), nil
+}
+
+func (u *utilsImpl) TimePIN(secret string, window int64) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.TrimRight(strings.ToUpper(secret), "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid pin secret: %w", err)
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(window))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
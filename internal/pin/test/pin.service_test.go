@@ -48,7 +48,7 @@ func (t *PinServiceTest) TestFindAllSuccess() {
 		},
 	}
 
-	repo.EXPECT().GetPin("workshop-1", &dto.Pin{}).SetArg(1, dto.Pin{Code: "123456"})
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Code: "123456"})
 
 	res, err := svc.FindAll(context.Background(), &proto.FindAllPinRequest{})
 	t.Equal(res, expectedResp)
@@ -60,7 +60,7 @@ func (t *PinServiceTest) TestFindAllNotEmptyError() {
 	utils := mock_pin.NewMockUtils(t.controller)
 	svc := pin.NewService(t.conf, utils, repo, t.logger)
 
-	repo.EXPECT().GetPin("workshop-1", &dto.Pin{}).Return(errors.New("some error that is not empty error"))
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).Return(errors.New("some error that is not empty error"))
 
 	res, err := svc.FindAll(context.Background(), &proto.FindAllPinRequest{})
 	t.Nil(res)
@@ -78,9 +78,9 @@ func (t *PinServiceTest) TestFindAllEmptyError() {
 		},
 	}
 
-	repo.EXPECT().GetPin("workshop-1", &dto.Pin{}).Return(errors.New("redis: nil"))
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).Return(errors.New("redis: nil"))
 	utils.EXPECT().GeneratePIN().Return("111111", nil)
-	repo.EXPECT().SetPin("workshop-1", &dto.Pin{Code: "111111"}).Return(nil)
+	repo.EXPECT().SetPin(gomock.Any(), "workshop-1", &dto.Pin{Code: "111111"}).Return(nil)
 
 	res, err := svc.FindAll(context.Background(), &proto.FindAllPinRequest{})
 	t.Equal(expectedResp, res)
@@ -105,8 +105,8 @@ func (t *PinServiceTest) TestFindMultiplePins() {
 		},
 	}
 
-	repo.EXPECT().GetPin("workshop-1", &dto.Pin{}).SetArg(1, dto.Pin{Code: "123456"})
-	repo.EXPECT().GetPin("landmark-1", &dto.Pin{}).SetArg(1, dto.Pin{Code: "654321"})
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Code: "123456"})
+	repo.EXPECT().GetPin(gomock.Any(), "landmark-1", &dto.Pin{}).SetArg(2, dto.Pin{Code: "654321"})
 
 	res, err := svc.FindAll(context.Background(), &proto.FindAllPinRequest{})
 	t.Equal(res, expectedResp)
@@ -115,3 +115,95 @@ func (t *PinServiceTest) TestFindMultiplePins() {
 
 func (t *PinServiceTest) TestResetPinSuccess() {
 }
+
+func (t *PinServiceTest) TestFindAllRotating() {
+	t.conf.Period = 60
+	repo := mock_pin.NewMockRepository(t.controller)
+	utils := mock_pin.NewMockUtils(t.controller)
+	svc := pin.NewService(t.conf, utils, repo, t.logger)
+
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Secret: "secret"})
+	utils.EXPECT().TimePIN("secret", gomock.Any()).Return("123456", nil)
+
+	res, err := svc.FindAll(context.Background(), &proto.FindAllPinRequest{})
+	t.Nil(err)
+	t.Equal("123456", res.Pins[0].Code)
+}
+
+func (t *PinServiceTest) TestFindAllRotatingReplacesStaticPin() {
+	t.conf.Period = 60
+	repo := mock_pin.NewMockRepository(t.controller)
+	utils := mock_pin.NewMockUtils(t.controller)
+	svc := pin.NewService(t.conf, utils, repo, t.logger)
+
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Code: "111111"})
+	utils.EXPECT().GenerateSecret().Return("secret", nil)
+	repo.EXPECT().SetPin(gomock.Any(), "workshop-1", &dto.Pin{Secret: "secret"}).Return(nil)
+	utils.EXPECT().TimePIN("secret", gomock.Any()).Return("123456", nil)
+
+	res, err := svc.FindAll(context.Background(), &proto.FindAllPinRequest{})
+	t.Nil(err)
+	t.Equal("123456", res.Pins[0].Code)
+}
+
+func (t *PinServiceTest) TestResetPinRotating() {
+	t.conf.Period = 60
+	repo := mock_pin.NewMockRepository(t.controller)
+	utils := mock_pin.NewMockUtils(t.controller)
+	svc := pin.NewService(t.conf, utils, repo, t.logger)
+
+	repo.EXPECT().DeletePin(gomock.Any(), "workshop-1").Return(nil)
+	utils.EXPECT().GenerateSecret().Return("secret", nil)
+	repo.EXPECT().SetPin(gomock.Any(), "workshop-1", &dto.Pin{Secret: "secret"}).Return(nil)
+	utils.EXPECT().TimePIN("secret", gomock.Any()).Return("123456", nil)
+
+	res, err := svc.ResetPin(context.Background(), &proto.ResetPinRequest{ActivityId: "workshop-1"})
+	t.Nil(err)
+	t.Equal("123456", res.Pin.Code)
+}
+
+func (t *PinServiceTest) TestCheckPinRotatingAcceptsPreviousWindow() {
+	t.conf.Period = 60
+	repo := mock_pin.NewMockRepository(t.controller)
+	utils := mock_pin.NewMockUtils(t.controller)
+	svc := pin.NewService(t.conf, utils, repo, t.logger)
+
+	var windows []int64
+	codes := []string{"222222", "111111"}
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Secret: "secret"})
+	utils.EXPECT().TimePIN("secret", gomock.Any()).Times(2).DoAndReturn(func(_ string, window int64) (string, error) {
+		windows = append(windows, window)
+		return codes[len(windows)-1], nil
+	})
+
+	res, err := svc.CheckPin(context.Background(), &proto.CheckPinRequest{ActivityId: "workshop-1", Code: "111111"})
+	t.Nil(err)
+	t.True(res.IsMatch)
+	t.Equal(windows[0]-1, windows[1])
+}
+
+func (t *PinServiceTest) TestCheckPinRotatingRejectsOlderWindow() {
+	t.conf.Period = 60
+	repo := mock_pin.NewMockRepository(t.controller)
+	utils := mock_pin.NewMockUtils(t.controller)
+	svc := pin.NewService(t.conf, utils, repo, t.logger)
+
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Secret: "secret"})
+	utils.EXPECT().TimePIN("secret", gomock.Any()).Times(2).Return("222222", nil)
+
+	res, err := svc.CheckPin(context.Background(), &proto.CheckPinRequest{ActivityId: "workshop-1", Code: "111111"})
+	t.Nil(err)
+	t.False(res.IsMatch)
+}
+
+func (t *PinServiceTest) TestCheckPinStatic() {
+	repo := mock_pin.NewMockRepository(t.controller)
+	utils := mock_pin.NewMockUtils(t.controller)
+	svc := pin.NewService(t.conf, utils, repo, t.logger)
+
+	repo.EXPECT().GetPin(gomock.Any(), "workshop-1", &dto.Pin{}).SetArg(2, dto.Pin{Code: "111111"})
+
+	res, err := svc.CheckPin(context.Background(), &proto.CheckPinRequest{ActivityId: "workshop-1", Code: "111111"})
+	t.Nil(err)
+	t.True(res.IsMatch)
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/isd-sgcu/rpkm67-backend/internal/pin"
+	"github.com/stretchr/testify/suite"
+)
+
+type PinUtilsTest struct {
+	suite.Suite
+	utils pin.Utils
+}
+
+func TestPinUtils(t *testing.T) {
+	suite.Run(t, new(PinUtilsTest))
+}
+
+func (t *PinUtilsTest) SetupTest() {
+	t.utils = pin.NewUtils()
+}
+
+// the SHA1 test vectors of RFC 6238, truncated to 6 digits, with a 30 second period
+func (t *PinUtilsTest) TestTimePINMatchesRFC6238() {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // base32 of 12345678901234567890
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := t.utils.TimePIN(secret, unix/30)
+		t.NoError(err)
+		t.Equal(expected, code, unix)
+	}
+}
+
+func (t *PinUtilsTest) TestTimePINInvalidSecret() {
+	_, err := t.utils.TimePIN("not base32!", 1)
+
+	t.Error(err)
+}
+
+func (t *PinUtilsTest) TestGenerateSecret() {
+	first, err := t.utils.GenerateSecret()
+	t.NoError(err)
+	second, err := t.utils.GenerateSecret()
+	t.NoError(err)
+
+	t.NotEqual(first, second)
+	_, err = t.utils.TimePIN(first, 1)
+	t.NoError(err)
+}
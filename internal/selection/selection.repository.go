@@ -1,18 +1,24 @@
 package selection
 
 import (
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
-	Create(user *model.Selection) error
+	WithTransaction(txFunc func(*gorm.DB) error) error
+	CreateTX(tx *gorm.DB, selection *model.Selection) error
 	FindByGroupId(groupId string, selections *[]model.Selection) error
-	Delete(groupId string, baanId string) error
+	FindByGroupIdTX(tx *gorm.DB, groupId string, selections *[]model.Selection) error
+	DeleteTX(tx *gorm.DB, groupId string, baanId string) error
 	CountByBaanId() (map[string]int, error)
-	UpdateNewBaanExistOrder(updateSelection *model.Selection) error
-	UpdateExistBaanExistOrder(updateSelection *model.Selection) error
-	UpdateExistBaanNewOrder(updateSelection *model.Selection) error
+	CountFirstChoiceMembersByBaanId() (map[string]int, error)
+	UpdateNewBaanExistOrderTX(tx *gorm.DB, updateSelection *model.Selection) error
+	UpdateExistBaanExistOrderTX(tx *gorm.DB, updateSelection *model.Selection) error
+	UpdateExistBaanNewOrderTX(tx *gorm.DB, updateSelection *model.Selection) error
+	ReplaceByGroupIdTX(tx *gorm.DB, groupId string, selections []*model.Selection) error
+	DeleteByGroupIdTX(tx *gorm.DB, groupId string) error
 }
 
 type repositoryImpl struct {
@@ -25,16 +31,24 @@ func NewRepository(db *gorm.DB) Repository {
 	}
 }
 
-func (r *repositoryImpl) Create(selection *model.Selection) error {
-	return r.Db.Create(selection).Error
+func (r *repositoryImpl) WithTransaction(txFunc func(*gorm.DB) error) error {
+	return utils.WithTransaction(r.Db, txFunc)
+}
+
+func (r *repositoryImpl) CreateTX(tx *gorm.DB, selection *model.Selection) error {
+	return tx.Create(selection).Error
 }
 
 func (r *repositoryImpl) FindByGroupId(groupId string, selections *[]model.Selection) error {
 	return r.Db.Find(selections, "group_id = ?", groupId).Error
 }
 
-func (r *repositoryImpl) Delete(groupId string, baanId string) error {
-	return r.Db.Delete(&model.Selection{}, "group_id = ? AND baan = ?", groupId, baanId).Error
+func (r *repositoryImpl) FindByGroupIdTX(tx *gorm.DB, groupId string, selections *[]model.Selection) error {
+	return tx.Find(selections, "group_id = ?", groupId).Error
+}
+
+func (r *repositoryImpl) DeleteTX(tx *gorm.DB, groupId string, baanId string) error {
+	return tx.Delete(&model.Selection{}, "group_id = ? AND baan = ?", groupId, baanId).Error
 }
 
 func (r *repositoryImpl) CountByBaanId() (map[string]int, error) {
@@ -54,59 +68,93 @@ func (r *repositoryImpl) CountByBaanId() (map[string]int, error) {
 	return count, nil
 }
 
-func (r *repositoryImpl) UpdateNewBaanExistOrder(updateSelection *model.Selection) error {
-	return r.Db.Transaction(func(tx *gorm.DB) error {
-		var existingSelection model.Selection
-		if err := tx.Where(`group_id = ? AND "order" = ?`, updateSelection.GroupID, updateSelection.Order).First(&existingSelection).Error; err != nil {
-			return err
-		}
+// CountFirstChoiceMembersByBaanId counts the members of the groups ranking each baan first
+func (r *repositoryImpl) CountFirstChoiceMembersByBaanId() (map[string]int, error) {
+	var result []struct {
+		Baan  string
+		Count int
+	}
+	err := r.Db.Model(&model.Selection{}).
+		Select("selections.baan, count(users.id) as count").
+		Joins("JOIN users ON users.group_id = selections.group_id AND users.deleted_at IS NULL").
+		Where(`selections."order" = ?`, 1).
+		Group("selections.baan").
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+
+	count := make(map[string]int)
+	for _, v := range result {
+		count[v.Baan] = v.Count
+	}
+
+	return count, nil
+}
 
-		if err := tx.Where(`"order" = ? AND group_id = ?`, updateSelection.Order, updateSelection.GroupID).Model(&existingSelection).Update("baan", updateSelection.Baan).Error; err != nil {
-			return err
-		}
+func (r *repositoryImpl) UpdateNewBaanExistOrderTX(tx *gorm.DB, updateSelection *model.Selection) error {
+	var existingSelection model.Selection
+	if err := tx.Where(`group_id = ? AND "order" = ?`, updateSelection.GroupID, updateSelection.Order).First(&existingSelection).Error; err != nil {
+		return err
+	}
 
-		return nil
-	})
+	if err := tx.Where(`"order" = ? AND group_id = ?`, updateSelection.Order, updateSelection.GroupID).Model(&existingSelection).Update("baan", updateSelection.Baan).Error; err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func (r *repositoryImpl) UpdateExistBaanExistOrder(updateSelection *model.Selection) error {
-	return r.Db.Transaction(func(tx *gorm.DB) error {
-		var existingBaanSelection model.Selection
-		if err := tx.Where("group_id = ? AND baan = ?", updateSelection.GroupID, updateSelection.Baan).First(&existingBaanSelection).Error; err != nil {
-			return err
-		}
-
-		var existingOrderSelection model.Selection
-		if err := tx.Where(`group_id = ? AND "order" = ?`, updateSelection.GroupID, updateSelection.Order).First(&existingOrderSelection).Error; err != nil {
-			return err
-		}
-
-		if existingBaanSelection.Order == updateSelection.Order {
-			return nil
-		}
-
-		if err := tx.Where(`"order" = ? AND group_id = ?`, existingBaanSelection.Order, updateSelection.GroupID).Model(&existingBaanSelection).Update("baan", existingOrderSelection.Baan).Error; err != nil {
-			return err
-		}
-		if err := tx.Where(`"order" = ? AND group_id = ?`, existingOrderSelection.Order, updateSelection.GroupID).Model(&existingOrderSelection).Update("baan", updateSelection.Baan).Error; err != nil {
-			return err
-		}
+func (r *repositoryImpl) UpdateExistBaanExistOrderTX(tx *gorm.DB, updateSelection *model.Selection) error {
+	var existingBaanSelection model.Selection
+	if err := tx.Where("group_id = ? AND baan = ?", updateSelection.GroupID, updateSelection.Baan).First(&existingBaanSelection).Error; err != nil {
+		return err
+	}
+
+	var existingOrderSelection model.Selection
+	if err := tx.Where(`group_id = ? AND "order" = ?`, updateSelection.GroupID, updateSelection.Order).First(&existingOrderSelection).Error; err != nil {
+		return err
+	}
 
+	if existingBaanSelection.Order == updateSelection.Order {
 		return nil
-	})
+	}
+
+	if err := tx.Where(`"order" = ? AND group_id = ?`, existingBaanSelection.Order, updateSelection.GroupID).Model(&existingBaanSelection).Update("baan", existingOrderSelection.Baan).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(`"order" = ? AND group_id = ?`, existingOrderSelection.Order, updateSelection.GroupID).Model(&existingOrderSelection).Update("baan", updateSelection.Baan).Error; err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func (r *repositoryImpl) UpdateExistBaanNewOrder(updateSelection *model.Selection) error {
-	return r.Db.Transaction(func(tx *gorm.DB) error {
-		var existingSelection model.Selection
-		if err := tx.Where("group_id = ? AND baan = ?", updateSelection.GroupID, updateSelection.Baan).First(&existingSelection).Error; err != nil {
-			return err
-		}
+func (r *repositoryImpl) UpdateExistBaanNewOrderTX(tx *gorm.DB, updateSelection *model.Selection) error {
+	var existingSelection model.Selection
+	if err := tx.Where("group_id = ? AND baan = ?", updateSelection.GroupID, updateSelection.Baan).First(&existingSelection).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("baan = ? AND group_id = ?", updateSelection.Baan, updateSelection.GroupID).Model(&existingSelection).Update("order", updateSelection.Order).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
 
-		if err := tx.Where("baan = ? AND group_id = ?", updateSelection.Baan, updateSelection.GroupID).Model(&existingSelection).Update("order", updateSelection.Order).Error; err != nil {
-			return err
-		}
+func (r *repositoryImpl) ReplaceByGroupIdTX(tx *gorm.DB, groupId string, selections []*model.Selection) error {
+	if err := tx.Delete(&model.Selection{}, "group_id = ?", groupId).Error; err != nil {
+		return err
+	}
 
+	if len(selections) == 0 {
 		return nil
-	})
+	}
+
+	return tx.Create(&selections).Error
+}
+
+func (r *repositoryImpl) DeleteByGroupIdTX(tx *gorm.DB, groupId string) error {
+	return tx.Delete(&model.Selection{}, "group_id = ?", groupId).Error
 }
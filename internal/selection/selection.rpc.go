@@ -0,0 +1,31 @@
+package selection
+
+import (
+	"context"
+
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	"google.golang.org/grpc"
+)
+
+// JSONServer is the part of Service whose messages are not in rpkm67-go-proto yet. It is served
+// by the SelectionJSONService with dto messages encoded by utils.JSONCodec.
+type JSONServer interface {
+	ReplaceAll(ctx context.Context, in *dto.ReplaceAllSelectionRequest) (*dto.ReplaceAllSelectionResponse, error)
+	CountCapacityByBaanId(ctx context.Context, in *dto.CountCapacityByBaanIdRequest) (*dto.CountCapacityByBaanIdResponse, error)
+}
+
+func RegisterSelectionJSONServiceServer(s grpc.ServiceRegistrar, srv JSONServer) {
+	s.RegisterService(&selectionJSONServiceDesc, srv)
+}
+
+var selectionJSONServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rpkm67.backend.selection.v1.SelectionJSONService",
+	HandlerType: (*JSONServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ReplaceAll", Handler: utils.UnaryHandler(constant.SelectionJSONService_ReplaceAll_FullMethodName, JSONServer.ReplaceAll)},
+		{MethodName: "CountCapacityByBaanId", Handler: utils.UnaryHandler(constant.SelectionJSONService_CountCapacityByBaanId_FullMethodName, JSONServer.CountCapacityByBaanId)},
+	},
+	Metadata: "internal/selection/selection.rpc.go",
+}
@@ -6,95 +6,130 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
+	"github.com/isd-sgcu/rpkm67-backend/internal/baan"
 	"github.com/isd-sgcu/rpkm67-backend/internal/cache"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
 	"github.com/isd-sgcu/rpkm67-backend/internal/group"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	"github.com/isd-sgcu/rpkm67-backend/internal/window"
 	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 type Service interface {
 	proto.SelectionServiceServer
+	CountCapacityByBaanId(ctx context.Context, in *dto.CountCapacityByBaanIdRequest) (*dto.CountCapacityByBaanIdResponse, error)
+	ReplaceAll(ctx context.Context, in *dto.ReplaceAllSelectionRequest) (*dto.ReplaceAllSelectionResponse, error)
 }
 
+// CountByBaanIdKey caches the number of groups selecting each baan
+const CountByBaanIdKey = "countByBaanId"
+
+// FirstChoiceMembersByBaanIdKey caches the members of the groups ranking each baan first
+const FirstChoiceMembersByBaanIdKey = "firstChoiceMembersByBaanId"
+
 type serviceImpl struct {
 	proto.UnimplementedSelectionServiceServer
 	repo      Repository
 	groupRepo group.Repository
-	cache     cache.Repository
+	baanRepo  baan.Repository
+	cache     cache.Aside
+	window    window.Window
 	conf      *config.SelectionConfig
 	log       *zap.Logger
 }
 
-func NewService(repo Repository, groupRepo group.Repository, cache cache.Repository, conf *config.SelectionConfig, log *zap.Logger) Service {
+func NewService(repo Repository, groupRepo group.Repository, baanRepo baan.Repository, cache cache.Aside, window window.Window, conf *config.SelectionConfig, log *zap.Logger) Service {
 	return &serviceImpl{
 		repo:      repo,
 		groupRepo: groupRepo,
+		baanRepo:  baanRepo,
 		cache:     cache,
+		window:    window,
 		conf:      conf,
 		log:       log,
 	}
 }
 
 func (s *serviceImpl) Create(ctx context.Context, in *proto.CreateSelectionRequest) (*proto.CreateSelectionResponse, error) {
-	isConfirmed, err := s.isGroupConfirmed(in.GroupId)
-	if err != nil {
-		s.log.Named("Create").Error(fmt.Sprintf("isGroupConfirmed: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-	if isConfirmed {
-		s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, "Group is confirmed, cannot create selection")
+	if err := s.window.Check(); err != nil {
+		s.log.Named("Create").Error("Check window: ", zap.Error(err))
+		return nil, err
 	}
 
-	groupUUID, err := uuid.Parse(in.GroupId)
-	if err != nil {
-		s.log.Named("Create").Error(fmt.Sprintf("Parse group id: %s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		isConfirmed, err := s.lockGroupTX(ctx, tx, in.GroupId)
+		if err != nil {
+			s.log.Named("Create").Error(fmt.Sprintf("lockGroupTX: group_id=%s", in.GroupId), zap.Error(err))
+			return err
+		}
+		if isConfirmed {
+			s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: group_id=%s", in.GroupId))
+			return status.Error(codes.InvalidArgument, "Group is confirmed, cannot create selection")
+		}
 
-	selections := &[]model.Selection{}
-	err = s.repo.FindByGroupId(in.GroupId, selections)
-	if err != nil {
-		s.log.Named("Create").Error(fmt.Sprintf("FindByGroupId: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
+		groupUUID, err := uuid.Parse(in.GroupId)
+		if err != nil {
+			s.log.Named("Create").Error(fmt.Sprintf("Parse group id: %s", in.GroupId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
 
-	//Check can not create selection with same order
-	for _, selection := range *selections {
-		if selection.Order == int(in.Order) {
-			s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: order=%d", in.Order), zap.Error(err))
-			return nil, status.Error(codes.Internal, "Can not create selection with same order")
+		selections := &[]model.Selection{}
+		err = s.repo.FindByGroupIdTX(tx, in.GroupId, selections)
+		if err != nil {
+			s.log.Named("Create").Error(fmt.Sprintf("FindByGroupIdTX: group_id=%s", in.GroupId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
 		}
-	}
 
-	//Check can not create selection with same baan
-	for _, selection := range *selections {
-		if selection.Baan == in.BaanId {
-			s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: baan_id=%s", in.BaanId), zap.Error(err))
-			return nil, status.Error(codes.Internal, "Can not create selection with same baan")
+		//Check can not create selection with same order
+		for _, selection := range *selections {
+			if selection.Order == int(in.Order) {
+				s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: order=%d", in.Order))
+				return status.Error(codes.Internal, "Can not create selection with same order")
+			}
 		}
-	}
 
-	//Order must be in range 1-5
-	if in.Order < 1 || in.Order > 5 {
-		s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: order=%d", in.Order), zap.Error(err))
-		return nil, status.Error(codes.Internal, "Order must be in range 1-5")
-	}
+		//Check can not create selection with same baan
+		for _, selection := range *selections {
+			if selection.Baan == in.BaanId {
+				s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: baan_id=%s", in.BaanId))
+				return status.Error(codes.Internal, "Can not create selection with same baan")
+			}
+		}
 
-	//Create selection
-	selection := model.Selection{
-		GroupID: &groupUUID,
-		Baan:    in.BaanId,
-		Order:   int(in.Order),
-	}
+		//Order must be in range 1-5
+		if in.Order < 1 || in.Order > 5 {
+			s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: order=%d", in.Order))
+			return status.Error(codes.Internal, "Order must be in range 1-5")
+		}
+
+		if err := s.checkBaan(in.BaanId); err != nil {
+			s.log.Named("Create").Error(fmt.Sprintf("checkBaan: baan_id=%s", in.BaanId), zap.Error(err))
+			return err
+		}
+
+		//Create selection
+		selection := model.Selection{
+			GroupID: &groupUUID,
+			Baan:    in.BaanId,
+			Order:   int(in.Order),
+		}
+
+		if err := s.repo.CreateTX(tx, &selection); err != nil {
+			s.log.Named("Create").Error(fmt.Sprintf("CreateTX: group_id=%s, baan_id=%s", in.GroupId, in.BaanId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		return nil
+	})
 
-	err = s.repo.Create(&selection)
 	if err != nil {
-		s.log.Named("Create").Error(fmt.Sprintf("Create: group_id=%s, baan_id=%s", in.GroupId, in.BaanId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, utils.TxStatusError(err)
 	}
 
 	res := proto.CreateSelectionResponse{
@@ -143,20 +178,32 @@ func (s *serviceImpl) FindByGroupId(ctx context.Context, in *proto.FindByGroupId
 }
 
 func (s *serviceImpl) Delete(ctx context.Context, in *proto.DeleteSelectionRequest) (*proto.DeleteSelectionResponse, error) {
-	isConfirmed, err := s.isGroupConfirmed(in.GroupId)
-	if err != nil {
-		s.log.Named("Create").Error(fmt.Sprintf("isGroupConfirmed: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-	if isConfirmed {
-		s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, "Group is confirmed, cannot delete selection")
+	if err := s.window.Check(); err != nil {
+		s.log.Named("Delete").Error("Check window: ", zap.Error(err))
+		return nil, err
 	}
 
-	err = s.repo.Delete(in.GroupId, in.BaanId)
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		isConfirmed, err := s.lockGroupTX(ctx, tx, in.GroupId)
+		if err != nil {
+			s.log.Named("Delete").Error(fmt.Sprintf("lockGroupTX: group_id=%s", in.GroupId), zap.Error(err))
+			return err
+		}
+		if isConfirmed {
+			s.log.Named("Delete").Error(fmt.Sprintf("Failed to delete selection: group_id=%s", in.GroupId))
+			return status.Error(codes.InvalidArgument, "Group is confirmed, cannot delete selection")
+		}
+
+		if err := s.repo.DeleteTX(tx, in.GroupId, in.BaanId); err != nil {
+			s.log.Named("Delete").Error(fmt.Sprintf("DeleteTX: group_id=%s, baan_id=%s", in.GroupId, in.BaanId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		return nil
+	})
+
 	if err != nil {
-		s.log.Named("Delete").Error(fmt.Sprintf("Delete: group_id=%s, baan_id=%s", in.GroupId, in.BaanId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, utils.TxStatusError(err)
 	}
 
 	s.log.Info("Selection deleted",
@@ -166,24 +213,90 @@ func (s *serviceImpl) Delete(ctx context.Context, in *proto.DeleteSelectionReque
 }
 
 func (s *serviceImpl) CountByBaanId(ctx context.Context, in *proto.CountByBaanIdSelectionRequest) (*proto.CountByBaanIdSelectionResponse, error) {
-	cachedKey := "countByBaanId"
-	var cachedCount *proto.CountByBaanIdSelectionResponse
+	res, err := s.countByBaanId(ctx)
+	if err != nil {
+		s.log.Named("CountByBaanId").Error("countByBaanId", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return res, nil
+}
 
-	err := s.cache.GetValue(cachedKey, &cachedCount)
-	if err == nil {
-		s.log.Named("CountByBaanId").Info("Count group by baan id found in cache")
-		return &proto.CountByBaanIdSelectionResponse{
-			BaanCounts: cachedCount.BaanCounts,
-		}, nil
+// CountCapacityByBaanId reports each baan with the members of the groups ranking it first, which is
+// comparable with its capacity in members
+func (s *serviceImpl) CountCapacityByBaanId(ctx context.Context, in *dto.CountCapacityByBaanIdRequest) (*dto.CountCapacityByBaanIdResponse, error) {
+	members := map[string]int{}
+	err := s.cache.Load(ctx, FirstChoiceMembersByBaanIdKey, &members, s.conf.CacheTTL, func() error {
+		count, err := s.repo.CountFirstChoiceMembersByBaanId()
+		if err != nil {
+			return err
+		}
+		members = count
+
+		return nil
+	})
+	if err != nil {
+		s.log.Named("CountCapacityByBaanId").Error("CountFirstChoiceMembersByBaanId", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	baans := []dto.Baan{}
+	if err := s.baanRepo.FindAll(&baans); err != nil {
+		s.log.Named("CountCapacityByBaanId").Error("FindAll baan", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &dto.CountCapacityByBaanIdResponse{
+		BaanCounts: make([]*dto.BaanCount, len(baans)),
+	}
+	for i := range baans {
+		res.BaanCounts[i] = &dto.BaanCount{
+			Baan:    &baans[i],
+			Members: members[baans[i].ID],
+		}
+	}
+
+	return res, nil
+}
+
+func (s *serviceImpl) countByBaanId(ctx context.Context) (*proto.CountByBaanIdSelectionResponse, error) {
+	res := &proto.CountByBaanIdSelectionResponse{}
+	err := s.cache.Load(ctx, CountByBaanIdKey, res, s.conf.CacheTTL, func() error {
+		countRPC, err := s.countByBaanIdNoCache()
+		if err != nil {
+			return err
+		}
+		res.BaanCounts = countRPC
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *serviceImpl) countByBaanIdNoCache() ([]*proto.BaanCount, error) {
 	count, err := s.repo.CountByBaanId()
 	if err != nil {
-		s.log.Named("CountByBaanId").Error("CountByBaanId", zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
+	}
+
+	baans := []dto.Baan{}
+	if err := s.baanRepo.FindAll(&baans); err != nil {
+		return nil, err
 	}
 
+	// every registered baan is reported, including the ones nobody has selected yet
 	countRPC := []*proto.BaanCount{}
+	for _, b := range baans {
+		countRPC = append(countRPC, &proto.BaanCount{
+			BaanId: b.ID,
+			Count:  int32(count[b.ID]),
+		})
+		delete(count, b.ID)
+	}
 	for k, v := range count {
 		bc := &proto.BaanCount{
 			BaanId: k,
@@ -192,85 +305,95 @@ func (s *serviceImpl) CountByBaanId(ctx context.Context, in *proto.CountByBaanId
 		countRPC = append(countRPC, bc)
 	}
 
-	res := proto.CountByBaanIdSelectionResponse{
-		BaanCounts: countRPC,
-	}
-
-	if err := s.cache.SetValue(cachedKey, &res, s.conf.CacheTTL); err != nil {
-		s.log.Named("CountByBaanId").Warn("Failed to set count group by baan id in cache", zap.Error(err))
-	}
-
 	s.log.Info("Count group by baan id",
-		zap.Any("count", count))
+		zap.Any("count", countRPC))
 
-	return &res, nil
+	return countRPC, nil
 }
 
 func (s *serviceImpl) Update(ctx context.Context, in *proto.UpdateSelectionRequest) (*proto.UpdateSelectionResponse, error) {
-	isConfirmed, err := s.isGroupConfirmed(in.GroupId)
-	if err != nil {
-		s.log.Named("Create").Error(fmt.Sprintf("isGroupConfirmed: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-	if isConfirmed {
-		s.log.Named("Create").Error(fmt.Sprintf("Failed to create selection: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.InvalidArgument, "Group is confirmed, cannot update selection")
+	if err := s.window.Check(); err != nil {
+		s.log.Named("Update").Error("Check window: ", zap.Error(err))
+		return nil, err
 	}
 
-	oldSelections := &[]model.Selection{}
+	err := s.repo.WithTransaction(func(tx *gorm.DB) error {
+		isConfirmed, err := s.lockGroupTX(ctx, tx, in.GroupId)
+		if err != nil {
+			s.log.Named("Update").Error(fmt.Sprintf("lockGroupTX: group_id=%s", in.GroupId), zap.Error(err))
+			return err
+		}
+		if isConfirmed {
+			s.log.Named("Update").Error(fmt.Sprintf("Failed to update selection: group_id=%s", in.GroupId))
+			return status.Error(codes.InvalidArgument, "Group is confirmed, cannot update selection")
+		}
 
-	err = s.repo.FindByGroupId(in.GroupId, oldSelections)
-	if err != nil {
-		s.log.Named("Update").Error(fmt.Sprintf("FindByGroupId: group_id=%s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
+		oldSelections := &[]model.Selection{}
 
-	groupUUID, err := uuid.Parse(in.GroupId)
-	if err != nil {
-		s.log.Named("Update").Error(fmt.Sprintf("Parse group id: %s", in.GroupId), zap.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
-	}
+		err = s.repo.FindByGroupIdTX(tx, in.GroupId, oldSelections)
+		if err != nil {
+			s.log.Named("Update").Error(fmt.Sprintf("FindByGroupIdTX: group_id=%s", in.GroupId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
 
-	//Order must be in range 1-5
-	if in.Order < 1 || in.Order > 5 {
-		s.log.Named("Update").Error(fmt.Sprintf("Failed to update selection: order=%d", in.Order), zap.Error(err))
-		return nil, status.Error(codes.Internal, "Order must be in range 1-5")
-	}
+		groupUUID, err := uuid.Parse(in.GroupId)
+		if err != nil {
+			s.log.Named("Update").Error(fmt.Sprintf("Parse group id: %s", in.GroupId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
 
-	newSelection := model.Selection{
-		GroupID: &groupUUID,
-		Baan:    in.BaanId,
-		Order:   int(in.Order),
-	}
+		//Order must be in range 1-5
+		if in.Order < 1 || in.Order > 5 {
+			s.log.Named("Update").Error(fmt.Sprintf("Failed to update selection: order=%d", in.Order))
+			return status.Error(codes.Internal, "Order must be in range 1-5")
+		}
 
-	// Check if the new Baan exists in oldSelections
-	baanExists := false
-	orderExists := false
-	for _, oldSel := range *oldSelections {
-		if oldSel.Baan == newSelection.Baan {
-			baanExists = true
+		if err := s.checkBaan(in.BaanId); err != nil {
+			s.log.Named("Update").Error(fmt.Sprintf("checkBaan: baan_id=%s", in.BaanId), zap.Error(err))
+			return err
 		}
-		if oldSel.Order == newSelection.Order {
-			orderExists = true
+
+		newSelection := model.Selection{
+			GroupID: &groupUUID,
+			Baan:    in.BaanId,
+			Order:   int(in.Order),
 		}
-	}
 
-	var updateErr error
+		// Check if the new Baan exists in oldSelections
+		baanExists := false
+		orderExists := false
+		for _, oldSel := range *oldSelections {
+			if oldSel.Baan == newSelection.Baan {
+				baanExists = true
+			}
+			if oldSel.Order == newSelection.Order {
+				orderExists = true
+			}
+		}
 
-	if !baanExists && orderExists {
-		updateErr = s.repo.UpdateNewBaanExistOrder(&newSelection)
-	} else if baanExists && orderExists {
-		updateErr = s.repo.UpdateExistBaanExistOrder(&newSelection)
-	} else if baanExists && !orderExists {
-		updateErr = s.repo.UpdateExistBaanNewOrder(&newSelection)
-	} else {
-		s.log.Named("Update").Error(fmt.Sprintf("Invalid update scenario: group_id=%s, baan_id=%s", in.GroupId, in.BaanId))
-		return nil, status.Error(codes.Internal, "Invalid update scenario")
-	}
+		var updateErr error
+
+		if !baanExists && orderExists {
+			updateErr = s.repo.UpdateNewBaanExistOrderTX(tx, &newSelection)
+		} else if baanExists && orderExists {
+			updateErr = s.repo.UpdateExistBaanExistOrderTX(tx, &newSelection)
+		} else if baanExists && !orderExists {
+			updateErr = s.repo.UpdateExistBaanNewOrderTX(tx, &newSelection)
+		} else {
+			s.log.Named("Update").Error(fmt.Sprintf("Invalid update scenario: group_id=%s, baan_id=%s", in.GroupId, in.BaanId))
+			return status.Error(codes.Internal, "Invalid update scenario")
+		}
+
+		if updateErr != nil {
+			s.log.Named("Update").Error(fmt.Sprintf("Update: group_id=%s, baan_id=%s", in.GroupId, in.BaanId), zap.Error(updateErr))
+			return status.Error(codes.Internal, updateErr.Error())
+		}
 
-	if updateErr != nil {
-		s.log.Named("Update").Error(fmt.Sprintf("Update: group_id=%s, baan_id=%s", in.GroupId, in.BaanId), zap.Error(updateErr))
-		return nil, status.Error(codes.Internal, updateErr.Error())
+		return nil
+	})
+
+	if err != nil {
+		return nil, utils.TxStatusError(err)
 	}
 
 	res := proto.UpdateSelectionResponse{
@@ -288,12 +411,108 @@ func (s *serviceImpl) Update(ctx context.Context, in *proto.UpdateSelectionReque
 	return &res, nil
 }
 
-func (s *serviceImpl) isGroupConfirmed(groupID string) (bool, error) {
+func (s *serviceImpl) ReplaceAll(ctx context.Context, in *dto.ReplaceAllSelectionRequest) (*dto.ReplaceAllSelectionResponse, error) {
+	if err := s.window.Check(); err != nil {
+		s.log.Named("ReplaceAll").Error("Check window: ", zap.Error(err))
+		return nil, err
+	}
+
+	groupUUID, err := uuid.Parse(in.GroupId)
+	if err != nil {
+		s.log.Named("ReplaceAll").Error(fmt.Sprintf("Parse group id: %s", in.GroupId), zap.Error(err))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if len(in.BaanIds) > 5 {
+		s.log.Named("ReplaceAll").Error(fmt.Sprintf("Failed to replace selections: %d baans", len(in.BaanIds)))
+		return nil, status.Error(codes.InvalidArgument, "Can not select more than 5 baans")
+	}
+
+	seen := make(map[string]bool, len(in.BaanIds))
+	selections := make([]*model.Selection, len(in.BaanIds))
+	for i, baanId := range in.BaanIds {
+		if seen[baanId] {
+			s.log.Named("ReplaceAll").Error(fmt.Sprintf("Failed to replace selections: duplicate baan_id=%s", baanId))
+			return nil, status.Error(codes.InvalidArgument, "Can not create selection with same baan")
+		}
+		seen[baanId] = true
+
+		if err := s.checkBaan(baanId); err != nil {
+			s.log.Named("ReplaceAll").Error(fmt.Sprintf("checkBaan: baan_id=%s", baanId), zap.Error(err))
+			return nil, err
+		}
+
+		selections[i] = &model.Selection{
+			GroupID: &groupUUID,
+			Baan:    baanId,
+			Order:   i + 1,
+		}
+	}
+
+	err = s.repo.WithTransaction(func(tx *gorm.DB) error {
+		isConfirmed, err := s.lockGroupTX(ctx, tx, in.GroupId)
+		if err != nil {
+			s.log.Named("ReplaceAll").Error(fmt.Sprintf("lockGroupTX: group_id=%s", in.GroupId), zap.Error(err))
+			return err
+		}
+		if isConfirmed {
+			s.log.Named("ReplaceAll").Error(fmt.Sprintf("Failed to replace selections: group_id=%s", in.GroupId))
+			return status.Error(codes.InvalidArgument, "Group is confirmed, cannot update selection")
+		}
+
+		if err := s.repo.ReplaceByGroupIdTX(tx, in.GroupId, selections); err != nil {
+			s.log.Named("ReplaceAll").Error(fmt.Sprintf("ReplaceByGroupIdTX: group_id=%s", in.GroupId), zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, utils.TxStatusError(err)
+	}
+
+	selectionRPC := make([]*proto.Selection, len(selections))
+	for i, m := range selections {
+		selectionRPC[i] = &proto.Selection{
+			GroupId: in.GroupId,
+			BaanId:  m.Baan,
+			Order:   int32(m.Order),
+		}
+	}
+
+	s.log.Info("Selections replaced",
+		zap.String("group_id", in.GroupId),
+		zap.Strings("baan_ids", in.BaanIds))
+
+	return &dto.ReplaceAllSelectionResponse{Selections: selectionRPC}, nil
+}
+
+// lockGroupTX locks the group row so that selection changes for the same group are applied one at a time,
+// and reports whether the group is confirmed. Only members of the group may change its selections.
+func (s *serviceImpl) lockGroupTX(ctx context.Context, tx *gorm.DB, groupID string) (bool, error) {
 	group := &model.Group{}
-	if err := s.groupRepo.FindOne(groupID, group); err != nil {
-		s.log.Named("isGroupConfirmed").Error(fmt.Sprintf("FindOne: group_id=%s", groupID), zap.Error(err))
+	if err := s.groupRepo.FindOneForUpdateTX(tx, groupID, group); err != nil {
+		s.log.Named("lockGroupTX").Error(fmt.Sprintf("FindOneForUpdateTX: group_id=%s", groupID), zap.Error(err))
+		return false, status.Error(codes.Internal, err.Error())
+	}
+
+	if err := auth.CheckMember(ctx, group.Members); err != nil {
+		s.log.Named("lockGroupTX").Error(fmt.Sprintf("CheckMember: group_id=%s", groupID), zap.Error(err))
 		return false, err
 	}
 
 	return group.IsConfirmed, nil
 }
+
+func (s *serviceImpl) checkBaan(baanId string) error {
+	b := &dto.Baan{}
+	if err := s.baanRepo.FindOne(baanId, b); err != nil {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("Baan %s does not exist", baanId))
+	}
+	if !b.IsOpen {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("Baan %s is closed for selection", baanId))
+	}
+
+	return nil
+}
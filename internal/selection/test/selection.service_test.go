@@ -2,30 +2,41 @@ package test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
+	"github.com/isd-sgcu/rpkm67-backend/internal/baan"
+	"github.com/isd-sgcu/rpkm67-backend/internal/dto"
 	service "github.com/isd-sgcu/rpkm67-backend/internal/selection"
+	"github.com/isd-sgcu/rpkm67-backend/internal/utils"
+	mock_baan "github.com/isd-sgcu/rpkm67-backend/mocks/baan"
 	mock_cache "github.com/isd-sgcu/rpkm67-backend/mocks/cache"
 	mock_group "github.com/isd-sgcu/rpkm67-backend/mocks/group"
 	mock_selection "github.com/isd-sgcu/rpkm67-backend/mocks/selection"
+	mock_window "github.com/isd-sgcu/rpkm67-backend/mocks/window"
 	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 type SelectionServiceTestSuite struct {
 	suite.Suite
 	ctrl          *gomock.Controller
 	mockRepo      *mock_selection.MockRepository
-	mockCache     *mock_cache.MockRepository
+	mockCache     *mock_cache.MockAside
 	mockGroupRepo *mock_group.MockRepository
-	service       proto.SelectionServiceServer
+	mockBaanRepo  *mock_baan.MockRepository
+	mockWindow    *mock_window.MockWindow
+	service       service.Service
 	ctx           context.Context
 	logger        *zap.Logger
 	config        *config.SelectionConfig
@@ -38,12 +49,19 @@ func TestSelectionServiceTestSuite(t *testing.T) {
 func (s *SelectionServiceTestSuite) SetupTest() {
 	s.ctrl = gomock.NewController(s.T())
 	s.mockRepo = mock_selection.NewMockRepository(s.ctrl)
-	s.mockCache = mock_cache.NewMockRepository(s.ctrl)
+	s.mockCache = mock_cache.NewMockAside(s.ctrl)
 	s.logger = zap.NewNop()
 	s.config = &config.SelectionConfig{CacheTTL: 3600}
 	s.mockGroupRepo = mock_group.NewMockRepository(s.ctrl)
-	s.service = service.NewService(s.mockRepo, s.mockGroupRepo, s.mockCache, s.config, s.logger)
-	s.ctx = context.Background()
+	s.mockBaanRepo = mock_baan.NewMockRepository(s.ctrl)
+	s.mockWindow = mock_window.NewMockWindow(s.ctrl)
+	s.mockWindow.EXPECT().Check().Return(nil).AnyTimes()
+	s.mockRepo.EXPECT().WithTransaction(gomock.Any()).DoAndReturn(func(txFunc func(*gorm.DB) error) error {
+		return txFunc(nil)
+	}).AnyTimes()
+	s.service = service.NewService(s.mockRepo, s.mockGroupRepo, s.mockBaanRepo, s.mockCache, s.mockWindow, s.config, s.logger)
+	// staff may change any group's selections, the member checks have their own tests
+	s.ctx = auth.WithCaller(context.Background(), &auth.Caller{UserId: uuid.NewString(), Role: constant.STAFF})
 }
 
 func (s *SelectionServiceTestSuite) TearDownTest() {
@@ -55,9 +73,10 @@ func (s *SelectionServiceTestSuite) TestCreate_Success() {
 	baanID := "baan1"
 	order := int32(1)
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).Return(nil)
-	s.mockRepo.EXPECT().Create(gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne(baanID, gomock.Any()).SetArg(1, dto.Baan{ID: baanID, IsOpen: true}).Return(nil)
+	s.mockRepo.EXPECT().CreateTX(gomock.Any(), gomock.Any()).Return(nil)
 
 	req := &proto.CreateSelectionRequest{
 		GroupId: groupID,
@@ -82,8 +101,8 @@ func (s *SelectionServiceTestSuite) TestCreate_InvalidOrder() {
 		Order:   6,
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).Return(nil)
 
 	_, err := s.service.Create(s.ctx, req)
 
@@ -100,8 +119,8 @@ func (s *SelectionServiceTestSuite) TestCreate_DuplicateBaan() {
 		{GroupID: &parsedUUID, Baan: baanID, Order: 1},
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).SetArg(1, existingSelections).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, existingSelections).Return(nil)
 
 	req := &proto.CreateSelectionRequest{
 		GroupId: groupID,
@@ -116,6 +135,60 @@ func (s *SelectionServiceTestSuite) TestCreate_DuplicateBaan() {
 	s.Contains(err.Error(), "Can not create selection with same baan")
 }
 
+func (s *SelectionServiceTestSuite) TestCreate_UnknownBaan() {
+	groupID := uuid.New().String()
+	req := &proto.CreateSelectionRequest{
+		GroupId: groupID,
+		BaanId:  "unknown",
+		Order:   1,
+	}
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne("unknown", gomock.Any()).Return(baan.ErrBaanNotFound)
+
+	_, err := s.service.Create(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *SelectionServiceTestSuite) TestCreate_ClosedBaan() {
+	groupID := uuid.New().String()
+	req := &proto.CreateSelectionRequest{
+		GroupId: groupID,
+		BaanId:  "baan1",
+		Order:   1,
+	}
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne("baan1", gomock.Any()).SetArg(1, dto.Baan{ID: "baan1", IsOpen: false}).Return(nil)
+
+	_, err := s.service.Create(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	s.Contains(err.Error(), "closed")
+}
+
+func (s *SelectionServiceTestSuite) TestCreate_WindowClosed() {
+	mockWindow := mock_window.NewMockWindow(s.ctrl)
+	mockWindow.EXPECT().Check().Return(status.Error(codes.FailedPrecondition, "selection window closed 1m0s ago"))
+	svc := service.NewService(s.mockRepo, s.mockGroupRepo, s.mockBaanRepo, s.mockCache, mockWindow, s.config, s.logger)
+
+	req := &proto.CreateSelectionRequest{
+		GroupId: uuid.New().String(),
+		BaanId:  "baan1",
+		Order:   1,
+	}
+
+	_, err := svc.Create(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.FailedPrecondition, status.Code(err))
+}
+
 func (s *SelectionServiceTestSuite) TestCreate_InvalidGroupID() {
 	req := &proto.CreateSelectionRequest{
 		GroupId: "invalid-uuid",
@@ -123,13 +196,48 @@ func (s *SelectionServiceTestSuite) TestCreate_InvalidGroupID() {
 		Order:   1,
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), "invalid-uuid", gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
 	_, err := s.service.Create(s.ctx, req)
 
 	s.Error(err)
 	s.Equal(codes.Internal, status.Code(err))
 }
 
+func (s *SelectionServiceTestSuite) TestCreate_GroupConfirmed() {
+	groupID := uuid.New().String()
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: true}).Return(nil)
+
+	req := &proto.CreateSelectionRequest{
+		GroupId: groupID,
+		BaanId:  "baan1",
+		Order:   1,
+	}
+
+	_, err := s.service.Create(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *SelectionServiceTestSuite) TestCreate_TxConflict() {
+	groupID := uuid.New().String()
+	mockRepo := mock_selection.NewMockRepository(s.ctrl)
+	mockRepo.EXPECT().WithTransaction(gomock.Any()).Return(fmt.Errorf("%w: deadlock detected", utils.ErrTxConflict))
+	svc := service.NewService(mockRepo, s.mockGroupRepo, s.mockBaanRepo, s.mockCache, s.mockWindow, s.config, s.logger)
+
+	req := &proto.CreateSelectionRequest{
+		GroupId: groupID,
+		BaanId:  "baan1",
+		Order:   1,
+	}
+
+	_, err := svc.Create(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.Aborted, status.Code(err))
+}
+
 func (s *SelectionServiceTestSuite) TestFindByGroupId_Success() {
 	groupID := uuid.New().String()
 	parsedUUID := uuid.MustParse(groupID)
@@ -155,8 +263,8 @@ func (s *SelectionServiceTestSuite) TestDelete_Success() {
 	groupID := uuid.New().String()
 	baanID := "baan1"
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().Delete(groupID, baanID).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().DeleteTX(gomock.Any(), groupID, baanID).Return(nil)
 
 	req := &proto.DeleteSelectionRequest{GroupId: groupID, BaanId: baanID}
 	res, err := s.service.Delete(s.ctx, req)
@@ -174,7 +282,7 @@ func (s *SelectionServiceTestSuite) TestCountByBaanId_CacheHit() {
 		},
 	}
 
-	s.mockCache.EXPECT().GetValue("countByBaanId", gomock.Any()).SetArg(1, cachedResponse).Return(nil)
+	s.expectCountCached(cachedResponse)
 
 	req := &proto.CountByBaanIdSelectionRequest{}
 	res, err := s.service.CountByBaanId(s.ctx, req)
@@ -190,16 +298,48 @@ func (s *SelectionServiceTestSuite) TestCountByBaanId_CacheMiss() {
 		"baan2": 3,
 	}
 
-	s.mockCache.EXPECT().GetValue("countByBaanId", gomock.Any()).Return(status.Error(codes.NotFound, "cache miss"))
+	s.mockCache.EXPECT().Load(gomock.Any(), "countByBaanId", gomock.Any(), s.config.CacheTTL, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ interface{}, _ int, load func() error) error { return load() })
 	s.mockRepo.EXPECT().CountByBaanId().Return(count, nil)
-	s.mockCache.EXPECT().SetValue("countByBaanId", gomock.Any(), s.config.CacheTTL).Return(nil)
+	s.mockBaanRepo.EXPECT().FindAll(gomock.Any()).SetArg(0, []dto.Baan{{ID: "baan1"}, {ID: "baan2"}, {ID: "baan3"}}).Return(nil)
 
 	req := &proto.CountByBaanIdSelectionRequest{}
 	res, err := s.service.CountByBaanId(s.ctx, req)
 
 	s.NoError(err)
 	s.NotNil(res)
+	s.Len(res.BaanCounts, 3)
+	s.Equal(int32(0), res.BaanCounts[2].Count)
+}
+
+// expectCountCached expects the baan counts to be found in the cache
+func (s *SelectionServiceTestSuite) expectCountCached(cached *proto.CountByBaanIdSelectionResponse) {
+	s.mockCache.EXPECT().Load(gomock.Any(), "countByBaanId", gomock.Any(), s.config.CacheTTL, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, value interface{}, _ int, _ func() error) error {
+			value.(*proto.CountByBaanIdSelectionResponse).BaanCounts = cached.BaanCounts
+			return nil
+		})
+}
+
+func (s *SelectionServiceTestSuite) TestCountCapacityByBaanId_Success() {
+	baans := []dto.Baan{
+		{ID: "baan1", Capacity: 30, IsOpen: true},
+		{ID: "baan2", Capacity: 40, IsOpen: true},
+	}
+
+	s.mockCache.EXPECT().Load(gomock.Any(), "firstChoiceMembersByBaanId", gomock.Any(), s.config.CacheTTL, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ interface{}, _ int, load func() error) error { return load() })
+	s.mockRepo.EXPECT().CountFirstChoiceMembersByBaanId().Return(map[string]int{"baan1": 12}, nil)
+	s.mockBaanRepo.EXPECT().FindAll(gomock.Any()).SetArg(0, baans).Return(nil)
+
+	res, err := s.service.CountCapacityByBaanId(s.ctx, &dto.CountCapacityByBaanIdRequest{})
+
+	s.NoError(err)
 	s.Len(res.BaanCounts, 2)
+	s.Equal(12, res.BaanCounts[0].Members)
+	s.Equal(30, res.BaanCounts[0].Baan.Capacity)
+	s.Equal(0, res.BaanCounts[1].Members)
+	s.Equal(40, res.BaanCounts[1].Baan.Capacity)
 }
 
 func (s *SelectionServiceTestSuite) TestUpdate_UpdateExistBaanNewOrderSuccess() {
@@ -212,9 +352,10 @@ func (s *SelectionServiceTestSuite) TestUpdate_UpdateExistBaanNewOrderSuccess()
 		{GroupID: &parsedUUID, Baan: baanID, Order: 1},
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).SetArg(1, oldSelections).Return(nil)
-	s.mockRepo.EXPECT().UpdateExistBaanNewOrder(gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, oldSelections).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne(baanID, gomock.Any()).SetArg(1, dto.Baan{ID: baanID, IsOpen: true}).Return(nil)
+	s.mockRepo.EXPECT().UpdateExistBaanNewOrderTX(gomock.Any(), gomock.Any()).Return(nil)
 
 	req := &proto.UpdateSelectionRequest{
 		GroupId: groupID,
@@ -242,9 +383,10 @@ func (s *SelectionServiceTestSuite) TestUpdate_UpdateExistBaanExistOrderSuccess(
 		{GroupID: &parsedUUID, Baan: "baan2", Order: int(order)},
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).SetArg(1, oldSelections).Return(nil)
-	s.mockRepo.EXPECT().UpdateExistBaanExistOrder(gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, oldSelections).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne(baanID, gomock.Any()).SetArg(1, dto.Baan{ID: baanID, IsOpen: true}).Return(nil)
+	s.mockRepo.EXPECT().UpdateExistBaanExistOrderTX(gomock.Any(), gomock.Any()).Return(nil)
 
 	req := &proto.UpdateSelectionRequest{
 		GroupId: groupID,
@@ -271,9 +413,10 @@ func (s *SelectionServiceTestSuite) TestUpdate_UpdateNewBaanExistOrderSuccess()
 		{GroupID: &parsedUUID, Baan: "baan2", Order: int(order)},
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).SetArg(1, oldSelections).Return(nil)
-	s.mockRepo.EXPECT().UpdateNewBaanExistOrder(gomock.Any()).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, oldSelections).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne(baanID, gomock.Any()).SetArg(1, dto.Baan{ID: baanID, IsOpen: true}).Return(nil)
+	s.mockRepo.EXPECT().UpdateNewBaanExistOrderTX(gomock.Any(), gomock.Any()).Return(nil)
 
 	req := &proto.UpdateSelectionRequest{
 		GroupId: groupID,
@@ -301,8 +444,9 @@ func (s *SelectionServiceTestSuite) TestUpdate_InvalidScenario() {
 		{GroupID: &parsedUUID, Baan: "baan2", Order: 2},
 	}
 
-	s.mockGroupRepo.EXPECT().FindOne(gomock.Any(), gomock.Any()).SetArg(1, model.Group{IsConfirmed: false}).Return(nil)
-	s.mockRepo.EXPECT().FindByGroupId(groupID, gomock.Any()).SetArg(1, oldSelections).Return(nil)
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockRepo.EXPECT().FindByGroupIdTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, oldSelections).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne(baanID, gomock.Any()).SetArg(1, dto.Baan{ID: baanID, IsOpen: true}).Return(nil)
 
 	req := &proto.UpdateSelectionRequest{
 		GroupId: groupID,
@@ -317,3 +461,108 @@ func (s *SelectionServiceTestSuite) TestUpdate_InvalidScenario() {
 	s.Equal(codes.Internal, status.Code(err))
 	s.Contains(err.Error(), "Invalid update scenario")
 }
+
+func (s *SelectionServiceTestSuite) TestReplaceAll_Success() {
+	groupID := uuid.New().String()
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{IsConfirmed: false}).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne("baan2", gomock.Any()).SetArg(1, dto.Baan{ID: "baan2", IsOpen: true}).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne("baan1", gomock.Any()).SetArg(1, dto.Baan{ID: "baan1", IsOpen: true}).Return(nil)
+	s.mockRepo.EXPECT().ReplaceByGroupIdTX(gomock.Any(), groupID, gomock.Len(2)).Return(nil)
+
+	req := &dto.ReplaceAllSelectionRequest{
+		GroupId: groupID,
+		BaanIds: []string{"baan2", "baan1"},
+	}
+
+	res, err := s.service.ReplaceAll(s.ctx, req)
+
+	s.NoError(err)
+	s.Len(res.Selections, 2)
+	s.Equal("baan2", res.Selections[0].BaanId)
+	s.Equal(int32(1), res.Selections[0].Order)
+	s.Equal("baan1", res.Selections[1].BaanId)
+	s.Equal(int32(2), res.Selections[1].Order)
+}
+
+func (s *SelectionServiceTestSuite) TestReplaceAll_Member() {
+	groupID := uuid.New()
+	member := &model.User{Base: model.Base{ID: uuid.New()}}
+	ctx := auth.WithCaller(context.Background(), &auth.Caller{UserId: member.ID.String(), Role: constant.USER})
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID.String(), gomock.Any()).SetArg(2, model.Group{Members: []*model.User{member}}).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne("baan1", gomock.Any()).SetArg(1, dto.Baan{ID: "baan1", IsOpen: true}).Return(nil)
+	s.mockRepo.EXPECT().ReplaceByGroupIdTX(gomock.Any(), groupID.String(), gomock.Len(1)).Return(nil)
+
+	res, err := s.service.ReplaceAll(ctx, &dto.ReplaceAllSelectionRequest{GroupId: groupID.String(), BaanIds: []string{"baan1"}})
+
+	s.NoError(err)
+	s.Len(res.Selections, 1)
+}
+
+func (s *SelectionServiceTestSuite) TestReplaceAll_NotMember() {
+	groupID := uuid.New()
+	ctx := auth.WithCaller(context.Background(), &auth.Caller{UserId: uuid.NewString(), Role: constant.USER})
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID.String(), gomock.Any()).
+		SetArg(2, model.Group{Members: []*model.User{{Base: model.Base{ID: uuid.New()}}}}).Return(nil)
+	s.mockBaanRepo.EXPECT().FindOne("baan1", gomock.Any()).SetArg(1, dto.Baan{ID: "baan1", IsOpen: true}).Return(nil)
+
+	res, err := s.service.ReplaceAll(ctx, &dto.ReplaceAllSelectionRequest{GroupId: groupID.String(), BaanIds: []string{"baan1"}})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *SelectionServiceTestSuite) TestCreate_NotMember() {
+	groupID := uuid.NewString()
+	ctx := auth.WithCaller(context.Background(), &auth.Caller{UserId: uuid.NewString(), Role: constant.USER})
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{}).Return(nil)
+
+	res, err := s.service.Create(ctx, &proto.CreateSelectionRequest{GroupId: groupID, BaanId: "baan1", Order: 1})
+
+	s.Nil(res)
+	s.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (s *SelectionServiceTestSuite) TestDelete_NoCaller() {
+	groupID := uuid.NewString()
+
+	s.mockGroupRepo.EXPECT().FindOneForUpdateTX(gomock.Any(), groupID, gomock.Any()).SetArg(2, model.Group{}).Return(nil)
+
+	res, err := s.service.Delete(context.Background(), &proto.DeleteSelectionRequest{GroupId: groupID, BaanId: "baan1"})
+
+	s.Nil(res)
+	s.Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *SelectionServiceTestSuite) TestReplaceAll_DuplicateBaan() {
+	groupID := uuid.New().String()
+
+	s.mockBaanRepo.EXPECT().FindOne("baan1", gomock.Any()).SetArg(1, dto.Baan{ID: "baan1", IsOpen: true}).Return(nil)
+
+	req := &dto.ReplaceAllSelectionRequest{
+		GroupId: groupID,
+		BaanIds: []string{"baan1", "baan1"},
+	}
+
+	_, err := s.service.ReplaceAll(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *SelectionServiceTestSuite) TestReplaceAll_TooManyBaans() {
+	groupID := uuid.New().String()
+
+	req := &dto.ReplaceAllSelectionRequest{
+		GroupId: groupID,
+		BaanIds: []string{"baan1", "baan2", "baan3", "baan4", "baan5", "baan6"},
+	}
+
+	_, err := s.service.ReplaceAll(s.ctx, req)
+
+	s.Error(err)
+	s.Equal(codes.InvalidArgument, status.Code(err))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
 	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/stamp/v1"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"go.uber.org/zap"
@@ -30,7 +31,12 @@ func NewService(repo Repository, activityIdToIdx map[string]int, log *zap.Logger
 	}
 }
 
-func (s *serviceImpl) FindByUserId(_ context.Context, in *proto.FindByUserIdStampRequest) (res *proto.FindByUserIdStampResponse, err error) {
+func (s *serviceImpl) FindByUserId(ctx context.Context, in *proto.FindByUserIdStampRequest) (res *proto.FindByUserIdStampResponse, err error) {
+	if err := auth.CheckSelf(ctx, in.UserId); err != nil {
+		s.log.Named("FindByUserId").Error("CheckSelf", zap.Error(err))
+		return nil, err
+	}
+
 	stamp := &model.Stamp{}
 
 	err = s.repo.FindByUserId(in.UserId, stamp)
@@ -42,7 +48,12 @@ func (s *serviceImpl) FindByUserId(_ context.Context, in *proto.FindByUserIdStam
 	return &proto.FindByUserIdStampResponse{Stamp: s.modelToProto(stamp)}, nil
 }
 
-func (s *serviceImpl) StampByUserId(_ context.Context, in *proto.StampByUserIdRequest) (res *proto.StampByUserIdResponse, err error) {
+func (s *serviceImpl) StampByUserId(ctx context.Context, in *proto.StampByUserIdRequest) (res *proto.StampByUserIdResponse, err error) {
+	if err := auth.CheckSelf(ctx, in.UserId); err != nil {
+		s.log.Named("StampByUserId").Error("CheckSelf", zap.Error(err))
+		return nil, err
+	}
+
 	stamp := &model.Stamp{}
 
 	err = s.repo.FindByUserId(in.UserId, stamp)
@@ -1,25 +1,70 @@
 package test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/isd-sgcu/rpkm67-backend/constant"
+	"github.com/isd-sgcu/rpkm67-backend/internal/auth"
+	"github.com/isd-sgcu/rpkm67-backend/internal/stamp"
+	mock_stamp "github.com/isd-sgcu/rpkm67-backend/mocks/stamp"
+	proto "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/stamp/v1"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type StampServiceTest struct {
 	suite.Suite
-	// controller *gomock.Controller
-	// logger     *zap.Logger
+	controller *gomock.Controller
+	logger     *zap.Logger
 }
 
 func TestStampService(t *testing.T) {
 	suite.Run(t, new(StampServiceTest))
 }
 
-func (t *StampServiceTest) SetupTest() {}
+func (t *StampServiceTest) SetupTest() {
+	t.controller = gomock.NewController(t.T())
+	t.logger = zap.NewNop()
+}
 
 func (t *StampServiceTest) TestFindByUserIdSuccess() {
 }
 
 func (t *StampServiceTest) TestStampByUserIdSuccess() {
 }
+
+func (t *StampServiceTest) TestFindByUserIdOtherCaller() {
+	repo := mock_stamp.NewMockRepository(t.controller)
+	svc := stamp.NewService(repo, constant.ActivityIdToIdx, t.logger)
+	ctx := auth.WithCaller(context.Background(), &auth.Caller{UserId: "user-1", Role: constant.USER})
+
+	res, err := svc.FindByUserId(ctx, &proto.FindByUserIdStampRequest{UserId: "user-2"})
+
+	t.Nil(res)
+	t.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (t *StampServiceTest) TestStampByUserIdOtherCaller() {
+	repo := mock_stamp.NewMockRepository(t.controller)
+	svc := stamp.NewService(repo, constant.ActivityIdToIdx, t.logger)
+	ctx := auth.WithCaller(context.Background(), &auth.Caller{UserId: "user-1", Role: constant.USER})
+
+	res, err := svc.StampByUserId(ctx, &proto.StampByUserIdRequest{UserId: "user-2", ActivityId: "workshop-1"})
+
+	t.Nil(res)
+	t.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (t *StampServiceTest) TestStampByUserIdNoCaller() {
+	repo := mock_stamp.NewMockRepository(t.controller)
+	svc := stamp.NewService(repo, constant.ActivityIdToIdx, t.logger)
+
+	res, err := svc.StampByUserId(context.Background(), &proto.StampByUserIdRequest{UserId: "user-1", ActivityId: "workshop-1"})
+
+	t.Nil(res)
+	t.Equal(codes.Unauthenticated, status.Code(err))
+}
@@ -4,10 +4,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/isd-sgcu/rpkm67-model/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	FindOne(id string, user *model.User) error
+	FindOneForUpdateTX(tx *gorm.DB, id string, user *model.User) error
 	AssignGroupTX(tx *gorm.DB, id string, groupID *uuid.UUID) error
 }
 
@@ -23,6 +25,11 @@ func (r *repositoryImpl) FindOne(id string, user *model.User) error {
 	return r.Db.Model(user).First(user, "id = ?", id).Error
 }
 
+// FindOneForUpdateTX locks the user row until tx ends, serializing changes to the user's group
+func (r *repositoryImpl) FindOneForUpdateTX(tx *gorm.DB, id string, user *model.User) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(user, "id = ?", id).Error
+}
+
 func (r *repositoryImpl) AssignGroupTX(tx *gorm.DB, id string, groupID *uuid.UUID) error {
 	return tx.Model(&model.User{}).Where("id = ?", id).Update("group_id", groupID).Error
 }
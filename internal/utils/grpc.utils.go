@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+)
+
+// JSONCodec encodes the dto messages of the methods that have no messages in rpkm67-go-proto yet.
+// Clients call those methods with the json content-subtype, grpc.CallContentSubtype("json").
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+// UnaryHandler serves a service method taking a dto request as a grpc unary method. The request
+// passes through the server interceptor under fullMethod, like a generated handler.
+func UnaryHandler[S any, Req any, Res any](fullMethod string, method func(S, context.Context, *Req) (*Res, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(Req)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+
+		if interceptor == nil {
+			return method(srv.(S), ctx, in)
+		}
+
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return method(srv.(S), ctx, req.(*Req))
+		}
+
+		return interceptor(ctx, in, info, handler)
+	}
+}
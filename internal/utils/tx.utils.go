@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const txAttempts = 3
+
+var ErrTxConflict = errors.New("transaction conflicted with a concurrent request")
+
+// WithTransaction runs txFunc in a transaction. When postgres aborts the transaction because it
+// raced with another one (serialization failure, deadlock or lock timeout), it is retried a few
+// times before giving up with ErrTxConflict.
+func WithTransaction(db *gorm.DB, txFunc func(*gorm.DB) error) error {
+	var err error
+	for attempt := 0; attempt < txAttempts; attempt++ {
+		err = runTransaction(db, txFunc)
+		if !isConflict(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrTxConflict, err.Error())
+}
+
+func runTransaction(db *gorm.DB, txFunc func(*gorm.DB) error) (err error) {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			err = fmt.Errorf("transaction panicked: %v", r)
+		}
+	}()
+
+	if err := txFunc(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func isConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	switch pgErr.Code {
+	case "40001", "40P01", "55P03": // serialization_failure, deadlock_detected, lock_not_available
+		return true
+	}
+
+	return false
+}
+
+// TxStatusError turns an error returned from WithTransaction into a grpc status error. Status errors
+// returned by txFunc are passed through as is.
+func TxStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, ErrTxConflict) {
+		return status.Error(codes.Aborted, "request conflicted with another change to the group, please try again")
+	}
+
+	return status.Error(codes.Internal, fmt.Sprintf("transaction failed: %s", err.Error()))
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"github.com/isd-sgcu/rpkm67-backend/internal/window"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type WindowTest struct {
+	suite.Suite
+}
+
+func TestWindow(t *testing.T) {
+	suite.Run(t, new(WindowTest))
+}
+
+func (t *WindowTest) TestCheckOpen() {
+	w := window.NewWindow(&config.SelectionConfig{
+		OpenAt:  time.Now().Add(-time.Hour),
+		CloseAt: time.Now().Add(time.Hour),
+	})
+
+	t.Nil(w.Check())
+	t.False(w.IsClosed())
+}
+
+func (t *WindowTest) TestCheckUnbounded() {
+	w := window.NewWindow(&config.SelectionConfig{})
+
+	t.Nil(w.Check())
+	t.False(w.IsClosed())
+}
+
+func (t *WindowTest) TestCheckNotYetOpen() {
+	w := window.NewWindow(&config.SelectionConfig{
+		OpenAt: time.Now().Add(time.Hour),
+	})
+
+	err := w.Check()
+	t.Equal(codes.FailedPrecondition, status.Code(err))
+	t.Contains(err.Error(), "opens in")
+}
+
+func (t *WindowTest) TestCheckClosed() {
+	w := window.NewWindow(&config.SelectionConfig{
+		CloseAt: time.Now().Add(-time.Minute),
+	})
+
+	err := w.Check()
+	t.Equal(codes.FailedPrecondition, status.Code(err))
+	t.Contains(err.Error(), "closed")
+	t.True(w.IsClosed())
+}
+
+func (t *WindowTest) TestOverride() {
+	w := window.NewWindow(&config.SelectionConfig{
+		CloseAt: time.Now().Add(-time.Minute),
+	})
+
+	w.SetOverride(true)
+	t.Nil(w.Check())
+
+	w.SetOverride(false)
+	t.NotNil(w.Check())
+}
+
+func (t *WindowTest) TestOnCloseAlreadyClosed() {
+	w := window.NewWindow(&config.SelectionConfig{
+		CloseAt: time.Now().Add(-time.Minute),
+	})
+
+	done := make(chan struct{})
+	window.OnClose(w, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fail("OnClose did not run for a closed window")
+	}
+}
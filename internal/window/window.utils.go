@@ -0,0 +1,79 @@
+package window
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/isd-sgcu/rpkm67-backend/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Window is the period in which groups can edit their members and baan selections.
+type Window interface {
+	// Check returns a FailedPrecondition error if the window is not open, unless staff have overridden it
+	Check() error
+	IsClosed() bool
+	CloseAt() time.Time
+	// SetOverride applies the override staff set in the window state
+	SetOverride(override bool)
+}
+
+type windowImpl struct {
+	openAt   time.Time
+	closeAt  time.Time
+	override atomic.Bool
+}
+
+func NewWindow(conf *config.SelectionConfig) Window {
+	return &windowImpl{
+		openAt:  conf.OpenAt,
+		closeAt: conf.CloseAt,
+	}
+}
+
+func (w *windowImpl) Check() error {
+	if w.override.Load() {
+		return nil
+	}
+
+	now := time.Now()
+	if !w.openAt.IsZero() && now.Before(w.openAt) {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("selection window opens in %v", w.openAt.Sub(now).Round(time.Second)))
+	}
+	if w.isClosed(now) {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("selection window closed %v ago", now.Sub(w.closeAt).Round(time.Second)))
+	}
+
+	return nil
+}
+
+func (w *windowImpl) IsClosed() bool {
+	return w.isClosed(time.Now())
+}
+
+func (w *windowImpl) CloseAt() time.Time {
+	return w.closeAt
+}
+
+func (w *windowImpl) SetOverride(override bool) {
+	w.override.Store(override)
+}
+
+func (w *windowImpl) isClosed(now time.Time) bool {
+	return !w.closeAt.IsZero() && !now.Before(w.closeAt)
+}
+
+// OnClose runs fn once the window closes, or right away if it is already closed.
+// It does nothing for a window without a close time. The returned func cancels it.
+func OnClose(w Window, fn func()) (stop func() bool) {
+	closeAt := w.CloseAt()
+	if closeAt.IsZero() {
+		return func() bool { return false }
+	}
+
+	timer := time.AfterFunc(time.Until(closeAt), fn)
+
+	return timer.Stop
+}
@@ -0,0 +1,156 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/admin/admin.service.go
+
+// Package mock_admin is a generated GoMock package.
+package mock_admin
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/isd-sgcu/rpkm67-backend/internal/dto"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Allocate mocks base method.
+func (m *MockService) Allocate(ctx context.Context, in *dto.AllocateAdminRequest) (*dto.AllocateResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allocate", ctx, in)
+	ret0, _ := ret[0].(*dto.AllocateResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allocate indicates an expected call of Allocate.
+func (mr *MockServiceMockRecorder) Allocate(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allocate", reflect.TypeOf((*MockService)(nil).Allocate), ctx, in)
+}
+
+// DeleteOrphanGroups mocks base method.
+func (m *MockService) DeleteOrphanGroups(ctx context.Context, in *dto.DeleteOrphanGroupsAdminRequest) (*dto.DeleteOrphanGroupsAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrphanGroups", ctx, in)
+	ret0, _ := ret[0].(*dto.DeleteOrphanGroupsAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOrphanGroups indicates an expected call of DeleteOrphanGroups.
+func (mr *MockServiceMockRecorder) DeleteOrphanGroups(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphanGroups", reflect.TypeOf((*MockService)(nil).DeleteOrphanGroups), ctx, in)
+}
+
+// FindEvents mocks base method.
+func (m *MockService) FindEvents(ctx context.Context, in *dto.FindEventsAdminRequest) (*dto.FindEventsAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEvents", ctx, in)
+	ret0, _ := ret[0].(*dto.FindEventsAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEvents indicates an expected call of FindEvents.
+func (mr *MockServiceMockRecorder) FindEvents(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEvents", reflect.TypeOf((*MockService)(nil).FindEvents), ctx, in)
+}
+
+// MergeGroups mocks base method.
+func (m *MockService) MergeGroups(ctx context.Context, in *dto.MergeGroupsAdminRequest) (*dto.MergeGroupsAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeGroups", ctx, in)
+	ret0, _ := ret[0].(*dto.MergeGroupsAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeGroups indicates an expected call of MergeGroups.
+func (mr *MockServiceMockRecorder) MergeGroups(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeGroups", reflect.TypeOf((*MockService)(nil).MergeGroups), ctx, in)
+}
+
+// MoveUser mocks base method.
+func (m *MockService) MoveUser(ctx context.Context, in *dto.MoveUserAdminRequest) (*dto.MoveUserAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveUser", ctx, in)
+	ret0, _ := ret[0].(*dto.MoveUserAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveUser indicates an expected call of MoveUser.
+func (mr *MockServiceMockRecorder) MoveUser(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveUser", reflect.TypeOf((*MockService)(nil).MoveUser), ctx, in)
+}
+
+// SetWindowOverride mocks base method.
+func (m *MockService) SetWindowOverride(ctx context.Context, in *dto.SetWindowOverrideAdminRequest) (*dto.SetWindowOverrideAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWindowOverride", ctx, in)
+	ret0, _ := ret[0].(*dto.SetWindowOverrideAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetWindowOverride indicates an expected call of SetWindowOverride.
+func (mr *MockServiceMockRecorder) SetWindowOverride(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWindowOverride", reflect.TypeOf((*MockService)(nil).SetWindowOverride), ctx, in)
+}
+
+// SplitGroup mocks base method.
+func (m *MockService) SplitGroup(ctx context.Context, in *dto.SplitGroupAdminRequest) (*dto.SplitGroupAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SplitGroup", ctx, in)
+	ret0, _ := ret[0].(*dto.SplitGroupAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SplitGroup indicates an expected call of SplitGroup.
+func (mr *MockServiceMockRecorder) SplitGroup(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SplitGroup", reflect.TypeOf((*MockService)(nil).SplitGroup), ctx, in)
+}
+
+// UpdateConfirm mocks base method.
+func (m *MockService) UpdateConfirm(ctx context.Context, in *dto.UpdateConfirmAdminRequest) (*dto.UpdateConfirmAdminResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfirm", ctx, in)
+	ret0, _ := ret[0].(*dto.UpdateConfirmAdminResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateConfirm indicates an expected call of UpdateConfirm.
+func (mr *MockServiceMockRecorder) UpdateConfirm(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfirm", reflect.TypeOf((*MockService)(nil).UpdateConfirm), ctx, in)
+}
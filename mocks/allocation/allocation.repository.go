@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/allocation/allocation.repository.go
+
+// Package mock_allocation is a generated GoMock package.
+package mock_allocation
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	allocation "github.com/isd-sgcu/rpkm67-backend/internal/allocation"
+	group "github.com/isd-sgcu/rpkm67-backend/internal/group"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindAll mocks base method.
+func (m *MockRepository) FindAll(allocations *[]allocation.Allocation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", allocations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockRepositoryMockRecorder) FindAll(allocations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockRepository)(nil).FindAll), allocations)
+}
+
+// FindCurrentSnapshots mocks base method.
+func (m *MockRepository) FindCurrentSnapshots(snapshots *[]group.ConfirmSnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCurrentSnapshots", snapshots)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindCurrentSnapshots indicates an expected call of FindCurrentSnapshots.
+func (mr *MockRepositoryMockRecorder) FindCurrentSnapshots(snapshots interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCurrentSnapshots", reflect.TypeOf((*MockRepository)(nil).FindCurrentSnapshots), snapshots)
+}
+
+// ReplaceAll mocks base method.
+func (m *MockRepository) ReplaceAll(allocations []*allocation.Allocation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceAll", allocations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceAll indicates an expected call of ReplaceAll.
+func (mr *MockRepositoryMockRecorder) ReplaceAll(allocations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceAll", reflect.TypeOf((*MockRepository)(nil).ReplaceAll), allocations)
+}
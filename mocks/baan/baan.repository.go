@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/baan/baan.repository.go
+
+// Package mock_baan is a generated GoMock package.
+package mock_baan
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/isd-sgcu/rpkm67-backend/internal/dto"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindAll mocks base method.
+func (m *MockRepository) FindAll(baans *[]dto.Baan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", baans)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockRepositoryMockRecorder) FindAll(baans interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockRepository)(nil).FindAll), baans)
+}
+
+// FindOne mocks base method.
+func (m *MockRepository) FindOne(id string, baan *dto.Baan) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOne", id, baan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindOne indicates an expected call of FindOne.
+func (mr *MockRepositoryMockRecorder) FindOne(id, baan interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOne", reflect.TypeOf((*MockRepository)(nil).FindOne), id, baan)
+}
@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/cache/cache.aside.go
+
+// Package mock_cache is a generated GoMock package.
+package mock_cache
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAside is a mock of Aside interface.
+type MockAside struct {
+	ctrl     *gomock.Controller
+	recorder *MockAsideMockRecorder
+}
+
+// MockAsideMockRecorder is the mock recorder for MockAside.
+type MockAsideMockRecorder struct {
+	mock *MockAside
+}
+
+// NewMockAside creates a new mock instance.
+func NewMockAside(ctrl *gomock.Controller) *MockAside {
+	mock := &MockAside{ctrl: ctrl}
+	mock.recorder = &MockAsideMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAside) EXPECT() *MockAsideMockRecorder {
+	return m.recorder
+}
+
+// Invalidate mocks base method.
+func (m *MockAside) Invalidate(ctx context.Context, keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Invalidate", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockAsideMockRecorder) Invalidate(ctx interface{}, keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockAside)(nil).Invalidate), varargs...)
+}
+
+// InvalidatePrefix mocks base method.
+func (m *MockAside) InvalidatePrefix(ctx context.Context, prefix string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidatePrefix", ctx, prefix)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidatePrefix indicates an expected call of InvalidatePrefix.
+func (mr *MockAsideMockRecorder) InvalidatePrefix(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidatePrefix", reflect.TypeOf((*MockAside)(nil).InvalidatePrefix), ctx, prefix)
+}
+
+// Load mocks base method.
+func (m *MockAside) Load(ctx context.Context, key string, value interface{}, ttl int, load func() error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", ctx, key, value, ttl, load)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockAsideMockRecorder) Load(ctx, key, value, ttl, load interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockAside)(nil).Load), ctx, key, value, ttl, load)
+}
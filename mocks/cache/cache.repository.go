@@ -5,6 +5,7 @@
 package mock_cache
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -33,44 +34,88 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// DeleteByPrefix mocks base method.
+func (m *MockRepository) DeleteByPrefix(ctx context.Context, prefix string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByPrefix", ctx, prefix)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByPrefix indicates an expected call of DeleteByPrefix.
+func (mr *MockRepositoryMockRecorder) DeleteByPrefix(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByPrefix", reflect.TypeOf((*MockRepository)(nil).DeleteByPrefix), ctx, prefix)
+}
+
 // DeleteValue mocks base method.
-func (m *MockRepository) DeleteValue(key string) error {
+func (m *MockRepository) DeleteValue(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteValue", key)
+	ret := m.ctrl.Call(m, "DeleteValue", ctx, key)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteValue indicates an expected call of DeleteValue.
-func (mr *MockRepositoryMockRecorder) DeleteValue(key interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) DeleteValue(ctx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteValue", reflect.TypeOf((*MockRepository)(nil).DeleteValue), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteValue", reflect.TypeOf((*MockRepository)(nil).DeleteValue), ctx, key)
 }
 
 // GetValue mocks base method.
-func (m *MockRepository) GetValue(key string, value interface{}) error {
+func (m *MockRepository) GetValue(ctx context.Context, key string, value interface{}) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetValue", key, value)
+	ret := m.ctrl.Call(m, "GetValue", ctx, key, value)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GetValue indicates an expected call of GetValue.
-func (mr *MockRepositoryMockRecorder) GetValue(key, value interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetValue(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValue", reflect.TypeOf((*MockRepository)(nil).GetValue), ctx, key, value)
+}
+
+// Increment mocks base method.
+func (m *MockRepository) Increment(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Increment", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Increment indicates an expected call of Increment.
+func (mr *MockRepositoryMockRecorder) Increment(ctx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValue", reflect.TypeOf((*MockRepository)(nil).GetValue), key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Increment", reflect.TypeOf((*MockRepository)(nil).Increment), ctx, key)
 }
 
 // SetValue mocks base method.
-func (m *MockRepository) SetValue(key string, value interface{}, ttl int) error {
+func (m *MockRepository) SetValue(ctx context.Context, key string, value interface{}, ttl int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetValue", key, value, ttl)
+	ret := m.ctrl.Call(m, "SetValue", ctx, key, value, ttl)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetValue indicates an expected call of SetValue.
-func (mr *MockRepositoryMockRecorder) SetValue(key, value, ttl interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) SetValue(ctx, key, value, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetValue", reflect.TypeOf((*MockRepository)(nil).SetValue), ctx, key, value, ttl)
+}
+
+// SetValueNX mocks base method.
+func (m *MockRepository) SetValueNX(ctx context.Context, key string, value interface{}, ttl int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetValueNX", ctx, key, value, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetValueNX indicates an expected call of SetValueNX.
+func (mr *MockRepositoryMockRecorder) SetValueNX(ctx, key, value, ttl interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetValue", reflect.TypeOf((*MockRepository)(nil).SetValue), key, value, ttl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetValueNX", reflect.TypeOf((*MockRepository)(nil).SetValueNX), ctx, key, value, ttl)
 }
@@ -9,6 +9,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
+	group "github.com/isd-sgcu/rpkm67-backend/internal/group"
 	model "github.com/isd-sgcu/rpkm67-model/model"
 	gorm "gorm.io/gorm"
 )
@@ -36,6 +37,63 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ConfirmAllTX mocks base method.
+func (m *MockRepository) ConfirmAllTX(tx *gorm.DB) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmAllTX", tx)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmAllTX indicates an expected call of ConfirmAllTX.
+func (mr *MockRepositoryMockRecorder) ConfirmAllTX(tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmAllTX", reflect.TypeOf((*MockRepository)(nil).ConfirmAllTX), tx)
+}
+
+// CreateEventsTX mocks base method.
+func (m *MockRepository) CreateEventsTX(tx *gorm.DB, events []*group.GroupEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEventsTX", tx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEventsTX indicates an expected call of CreateEventsTX.
+func (mr *MockRepositoryMockRecorder) CreateEventsTX(tx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEventsTX", reflect.TypeOf((*MockRepository)(nil).CreateEventsTX), tx, events)
+}
+
+// CreateJoinRequestTX mocks base method.
+func (m *MockRepository) CreateJoinRequestTX(tx *gorm.DB, joinRequest *group.JoinRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJoinRequestTX", tx, joinRequest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJoinRequestTX indicates an expected call of CreateJoinRequestTX.
+func (mr *MockRepositoryMockRecorder) CreateJoinRequestTX(tx, joinRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJoinRequestTX", reflect.TypeOf((*MockRepository)(nil).CreateJoinRequestTX), tx, joinRequest)
+}
+
+// CreateSnapshotTX mocks base method.
+func (m *MockRepository) CreateSnapshotTX(tx *gorm.DB, groupId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSnapshotTX", tx, groupId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSnapshotTX indicates an expected call of CreateSnapshotTX.
+func (mr *MockRepositoryMockRecorder) CreateSnapshotTX(tx, groupId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshotTX", reflect.TypeOf((*MockRepository)(nil).CreateSnapshotTX), tx, groupId)
+}
+
 // CreateTX mocks base method.
 func (m *MockRepository) CreateTX(tx *gorm.DB, group *model.Group) error {
 	m.ctrl.T.Helper()
@@ -50,6 +108,20 @@ func (mr *MockRepositoryMockRecorder) CreateTX(tx, group interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTX", reflect.TypeOf((*MockRepository)(nil).CreateTX), tx, group)
 }
 
+// DeleteExpiredJoinRequests mocks base method.
+func (m *MockRepository) DeleteExpiredJoinRequests(groupId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredJoinRequests", groupId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredJoinRequests indicates an expected call of DeleteExpiredJoinRequests.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredJoinRequests(groupId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredJoinRequests", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredJoinRequests), groupId)
+}
+
 // DeleteGroupTX mocks base method.
 func (m *MockRepository) DeleteGroupTX(tx *gorm.DB, groupId *uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -64,6 +136,34 @@ func (mr *MockRepositoryMockRecorder) DeleteGroupTX(tx, groupId interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupTX", reflect.TypeOf((*MockRepository)(nil).DeleteGroupTX), tx, groupId)
 }
 
+// DeleteJoinRequestTX mocks base method.
+func (m *MockRepository) DeleteJoinRequestTX(tx *gorm.DB, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteJoinRequestTX", tx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteJoinRequestTX indicates an expected call of DeleteJoinRequestTX.
+func (mr *MockRepositoryMockRecorder) DeleteJoinRequestTX(tx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJoinRequestTX", reflect.TypeOf((*MockRepository)(nil).DeleteJoinRequestTX), tx, id)
+}
+
+// DeleteJoinRequestsByUserIdTX mocks base method.
+func (m *MockRepository) DeleteJoinRequestsByUserIdTX(tx *gorm.DB, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteJoinRequestsByUserIdTX", tx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteJoinRequestsByUserIdTX indicates an expected call of DeleteJoinRequestsByUserIdTX.
+func (mr *MockRepositoryMockRecorder) DeleteJoinRequestsByUserIdTX(tx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteJoinRequestsByUserIdTX", reflect.TypeOf((*MockRepository)(nil).DeleteJoinRequestsByUserIdTX), tx, userId)
+}
+
 // FindByToken mocks base method.
 func (m *MockRepository) FindByToken(token string, group *model.Group) error {
 	m.ctrl.T.Helper()
@@ -78,6 +178,90 @@ func (mr *MockRepositoryMockRecorder) FindByToken(token, group interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByToken", reflect.TypeOf((*MockRepository)(nil).FindByToken), token, group)
 }
 
+// FindByTokenForUpdateTX mocks base method.
+func (m *MockRepository) FindByTokenForUpdateTX(tx *gorm.DB, token string, group *model.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByTokenForUpdateTX", tx, token, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindByTokenForUpdateTX indicates an expected call of FindByTokenForUpdateTX.
+func (mr *MockRepositoryMockRecorder) FindByTokenForUpdateTX(tx, token, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByTokenForUpdateTX", reflect.TypeOf((*MockRepository)(nil).FindByTokenForUpdateTX), tx, token, group)
+}
+
+// FindEventsByGroupId mocks base method.
+func (m *MockRepository) FindEventsByGroupId(groupId string, offset, limit int, events *[]group.GroupEvent, total *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEventsByGroupId", groupId, offset, limit, events, total)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindEventsByGroupId indicates an expected call of FindEventsByGroupId.
+func (mr *MockRepositoryMockRecorder) FindEventsByGroupId(groupId, offset, limit, events, total interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEventsByGroupId", reflect.TypeOf((*MockRepository)(nil).FindEventsByGroupId), groupId, offset, limit, events, total)
+}
+
+// FindEventsByUserId mocks base method.
+func (m *MockRepository) FindEventsByUserId(userId string, offset, limit int, events *[]group.GroupEvent, total *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEventsByUserId", userId, offset, limit, events, total)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindEventsByUserId indicates an expected call of FindEventsByUserId.
+func (mr *MockRepositoryMockRecorder) FindEventsByUserId(userId, offset, limit, events, total interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEventsByUserId", reflect.TypeOf((*MockRepository)(nil).FindEventsByUserId), userId, offset, limit, events, total)
+}
+
+// FindJoinRequest mocks base method.
+func (m *MockRepository) FindJoinRequest(id string, joinRequest *group.JoinRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindJoinRequest", id, joinRequest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindJoinRequest indicates an expected call of FindJoinRequest.
+func (mr *MockRepositoryMockRecorder) FindJoinRequest(id, joinRequest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindJoinRequest", reflect.TypeOf((*MockRepository)(nil).FindJoinRequest), id, joinRequest)
+}
+
+// FindJoinRequestsByGroupId mocks base method.
+func (m *MockRepository) FindJoinRequestsByGroupId(groupId string, joinRequests *[]group.JoinRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindJoinRequestsByGroupId", groupId, joinRequests)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindJoinRequestsByGroupId indicates an expected call of FindJoinRequestsByGroupId.
+func (mr *MockRepositoryMockRecorder) FindJoinRequestsByGroupId(groupId, joinRequests interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindJoinRequestsByGroupId", reflect.TypeOf((*MockRepository)(nil).FindJoinRequestsByGroupId), groupId, joinRequests)
+}
+
+// FindMembershipsTX mocks base method.
+func (m *MockRepository) FindMembershipsTX(tx *gorm.DB, groupId string, memberships *[]group.Membership) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindMembershipsTX", tx, groupId, memberships)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindMembershipsTX indicates an expected call of FindMembershipsTX.
+func (mr *MockRepositoryMockRecorder) FindMembershipsTX(tx, groupId, memberships interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMembershipsTX", reflect.TypeOf((*MockRepository)(nil).FindMembershipsTX), tx, groupId, memberships)
+}
+
 // FindOne mocks base method.
 func (m *MockRepository) FindOne(id string, group *model.Group) error {
 	m.ctrl.T.Helper()
@@ -92,18 +276,200 @@ func (mr *MockRepositoryMockRecorder) FindOne(id, group interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOne", reflect.TypeOf((*MockRepository)(nil).FindOne), id, group)
 }
 
-// UpdateConfirm mocks base method.
-func (m *MockRepository) UpdateConfirm(id string, group *model.Group) error {
+// FindOneForUpdateTX mocks base method.
+func (m *MockRepository) FindOneForUpdateTX(tx *gorm.DB, id string, group *model.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOneForUpdateTX", tx, id, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindOneForUpdateTX indicates an expected call of FindOneForUpdateTX.
+func (mr *MockRepositoryMockRecorder) FindOneForUpdateTX(tx, id, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneForUpdateTX", reflect.TypeOf((*MockRepository)(nil).FindOneForUpdateTX), tx, id, group)
+}
+
+// FindOrphansForUpdateTX mocks base method.
+func (m *MockRepository) FindOrphansForUpdateTX(tx *gorm.DB, groups *[]model.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOrphansForUpdateTX", tx, groups)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindOrphansForUpdateTX indicates an expected call of FindOrphansForUpdateTX.
+func (mr *MockRepositoryMockRecorder) FindOrphansForUpdateTX(tx, groups interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrphansForUpdateTX", reflect.TypeOf((*MockRepository)(nil).FindOrphansForUpdateTX), tx, groups)
+}
+
+// FindTokenPolicy mocks base method.
+func (m *MockRepository) FindTokenPolicy(groupId string, policy *group.TokenPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTokenPolicy", groupId, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindTokenPolicy indicates an expected call of FindTokenPolicy.
+func (mr *MockRepositoryMockRecorder) FindTokenPolicy(groupId, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTokenPolicy", reflect.TypeOf((*MockRepository)(nil).FindTokenPolicy), groupId, policy)
+}
+
+// FindTokenPolicyTX mocks base method.
+func (m *MockRepository) FindTokenPolicyTX(tx *gorm.DB, groupId string, policy *group.TokenPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTokenPolicyTX", tx, groupId, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindTokenPolicyTX indicates an expected call of FindTokenPolicyTX.
+func (mr *MockRepositoryMockRecorder) FindTokenPolicyTX(tx, groupId, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTokenPolicyTX", reflect.TypeOf((*MockRepository)(nil).FindTokenPolicyTX), tx, groupId, policy)
+}
+
+// FindWindowState mocks base method.
+func (m *MockRepository) FindWindowState(state *group.WindowState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWindowState", state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindWindowState indicates an expected call of FindWindowState.
+func (mr *MockRepositoryMockRecorder) FindWindowState(state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWindowState", reflect.TypeOf((*MockRepository)(nil).FindWindowState), state)
+}
+
+// FindWindowStateForUpdateTX mocks base method.
+func (m *MockRepository) FindWindowStateForUpdateTX(tx *gorm.DB, state *group.WindowState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWindowStateForUpdateTX", tx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindWindowStateForUpdateTX indicates an expected call of FindWindowStateForUpdateTX.
+func (mr *MockRepositoryMockRecorder) FindWindowStateForUpdateTX(tx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWindowStateForUpdateTX", reflect.TypeOf((*MockRepository)(nil).FindWindowStateForUpdateTX), tx, state)
+}
+
+// IncrementTokenUsesTX mocks base method.
+func (m *MockRepository) IncrementTokenUsesTX(tx *gorm.DB, groupId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementTokenUsesTX", tx, groupId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementTokenUsesTX indicates an expected call of IncrementTokenUsesTX.
+func (mr *MockRepositoryMockRecorder) IncrementTokenUsesTX(tx, groupId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementTokenUsesTX", reflect.TypeOf((*MockRepository)(nil).IncrementTokenUsesTX), tx, groupId)
+}
+
+// SaveMembershipTX mocks base method.
+func (m *MockRepository) SaveMembershipTX(tx *gorm.DB, membership *group.Membership) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMembershipTX", tx, membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMembershipTX indicates an expected call of SaveMembershipTX.
+func (mr *MockRepositoryMockRecorder) SaveMembershipTX(tx, membership interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMembershipTX", reflect.TypeOf((*MockRepository)(nil).SaveMembershipTX), tx, membership)
+}
+
+// SaveTokenPolicyTX mocks base method.
+func (m *MockRepository) SaveTokenPolicyTX(tx *gorm.DB, policy *group.TokenPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTokenPolicyTX", tx, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTokenPolicyTX indicates an expected call of SaveTokenPolicyTX.
+func (mr *MockRepositoryMockRecorder) SaveTokenPolicyTX(tx, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTokenPolicyTX", reflect.TypeOf((*MockRepository)(nil).SaveTokenPolicyTX), tx, policy)
+}
+
+// SaveWindowStateTX mocks base method.
+func (m *MockRepository) SaveWindowStateTX(tx *gorm.DB, state *group.WindowState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWindowStateTX", tx, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWindowStateTX indicates an expected call of SaveWindowStateTX.
+func (mr *MockRepositoryMockRecorder) SaveWindowStateTX(tx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWindowStateTX", reflect.TypeOf((*MockRepository)(nil).SaveWindowStateTX), tx, state)
+}
+
+// SupersedeSnapshotTX mocks base method.
+func (m *MockRepository) SupersedeSnapshotTX(tx *gorm.DB, groupId string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateConfirm", id, group)
+	ret := m.ctrl.Call(m, "SupersedeSnapshotTX", tx, groupId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateConfirm indicates an expected call of UpdateConfirm.
-func (mr *MockRepositoryMockRecorder) UpdateConfirm(id, group interface{}) *gomock.Call {
+// SupersedeSnapshotTX indicates an expected call of SupersedeSnapshotTX.
+func (mr *MockRepositoryMockRecorder) SupersedeSnapshotTX(tx, groupId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfirm", reflect.TypeOf((*MockRepository)(nil).UpdateConfirm), id, group)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SupersedeSnapshotTX", reflect.TypeOf((*MockRepository)(nil).SupersedeSnapshotTX), tx, groupId)
+}
+
+// UpdateConfirmTX mocks base method.
+func (m *MockRepository) UpdateConfirmTX(tx *gorm.DB, id string, group *model.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfirmTX", tx, id, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConfirmTX indicates an expected call of UpdateConfirmTX.
+func (mr *MockRepositoryMockRecorder) UpdateConfirmTX(tx, id, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfirmTX", reflect.TypeOf((*MockRepository)(nil).UpdateConfirmTX), tx, id, group)
+}
+
+// UpdateLeaderTX mocks base method.
+func (m *MockRepository) UpdateLeaderTX(tx *gorm.DB, id string, leaderId *uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLeaderTX", tx, id, leaderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLeaderTX indicates an expected call of UpdateLeaderTX.
+func (mr *MockRepositoryMockRecorder) UpdateLeaderTX(tx, id, leaderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLeaderTX", reflect.TypeOf((*MockRepository)(nil).UpdateLeaderTX), tx, id, leaderId)
+}
+
+// UpdateTokenTX mocks base method.
+func (m *MockRepository) UpdateTokenTX(tx *gorm.DB, id, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTokenTX", tx, id, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTokenTX indicates an expected call of UpdateTokenTX.
+func (mr *MockRepositoryMockRecorder) UpdateTokenTX(tx, id, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTokenTX", reflect.TypeOf((*MockRepository)(nil).UpdateTokenTX), tx, id, token)
 }
 
 // WithTransaction mocks base method.
@@ -119,3 +485,68 @@ func (mr *MockRepositoryMockRecorder) WithTransaction(txFunc interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockRepository)(nil).WithTransaction), txFunc)
 }
+
+// MockSelectionRepository is a mock of SelectionRepository interface.
+type MockSelectionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSelectionRepositoryMockRecorder
+}
+
+// MockSelectionRepositoryMockRecorder is the mock recorder for MockSelectionRepository.
+type MockSelectionRepositoryMockRecorder struct {
+	mock *MockSelectionRepository
+}
+
+// NewMockSelectionRepository creates a new mock instance.
+func NewMockSelectionRepository(ctrl *gomock.Controller) *MockSelectionRepository {
+	mock := &MockSelectionRepository{ctrl: ctrl}
+	mock.recorder = &MockSelectionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSelectionRepository) EXPECT() *MockSelectionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteByGroupIdTX mocks base method.
+func (m *MockSelectionRepository) DeleteByGroupIdTX(tx *gorm.DB, groupId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByGroupIdTX", tx, groupId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByGroupIdTX indicates an expected call of DeleteByGroupIdTX.
+func (mr *MockSelectionRepositoryMockRecorder) DeleteByGroupIdTX(tx, groupId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByGroupIdTX", reflect.TypeOf((*MockSelectionRepository)(nil).DeleteByGroupIdTX), tx, groupId)
+}
+
+// FindByGroupIdTX mocks base method.
+func (m *MockSelectionRepository) FindByGroupIdTX(tx *gorm.DB, groupId string, selections *[]model.Selection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByGroupIdTX", tx, groupId, selections)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindByGroupIdTX indicates an expected call of FindByGroupIdTX.
+func (mr *MockSelectionRepositoryMockRecorder) FindByGroupIdTX(tx, groupId, selections interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByGroupIdTX", reflect.TypeOf((*MockSelectionRepository)(nil).FindByGroupIdTX), tx, groupId, selections)
+}
+
+// ReplaceByGroupIdTX mocks base method.
+func (m *MockSelectionRepository) ReplaceByGroupIdTX(tx *gorm.DB, groupId string, selections []*model.Selection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceByGroupIdTX", tx, groupId, selections)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceByGroupIdTX indicates an expected call of ReplaceByGroupIdTX.
+func (mr *MockSelectionRepositoryMockRecorder) ReplaceByGroupIdTX(tx, groupId, selections interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceByGroupIdTX", reflect.TypeOf((*MockSelectionRepository)(nil).ReplaceByGroupIdTX), tx, groupId, selections)
+}
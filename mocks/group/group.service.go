@@ -0,0 +1,243 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/group/group.service.go
+
+// Package mock_group is a generated GoMock package.
+package mock_group
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dto "github.com/isd-sgcu/rpkm67-backend/internal/dto"
+	v1 "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/group/v1"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// AcceptJoinRequest mocks base method.
+func (m *MockService) AcceptJoinRequest(ctx context.Context, in *dto.AcceptJoinRequestGroupRequest) (*dto.AcceptJoinRequestGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptJoinRequest", ctx, in)
+	ret0, _ := ret[0].(*dto.AcceptJoinRequestGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptJoinRequest indicates an expected call of AcceptJoinRequest.
+func (mr *MockServiceMockRecorder) AcceptJoinRequest(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptJoinRequest", reflect.TypeOf((*MockService)(nil).AcceptJoinRequest), ctx, in)
+}
+
+// DeleteMember mocks base method.
+func (m *MockService) DeleteMember(arg0 context.Context, arg1 *v1.DeleteMemberGroupRequest) (*v1.DeleteMemberGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMember", arg0, arg1)
+	ret0, _ := ret[0].(*v1.DeleteMemberGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMember indicates an expected call of DeleteMember.
+func (mr *MockServiceMockRecorder) DeleteMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMember", reflect.TypeOf((*MockService)(nil).DeleteMember), arg0, arg1)
+}
+
+// Disband mocks base method.
+func (m *MockService) Disband(ctx context.Context, in *dto.DisbandGroupRequest) (*dto.DisbandGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disband", ctx, in)
+	ret0, _ := ret[0].(*dto.DisbandGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Disband indicates an expected call of Disband.
+func (mr *MockServiceMockRecorder) Disband(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disband", reflect.TypeOf((*MockService)(nil).Disband), ctx, in)
+}
+
+// FindByToken mocks base method.
+func (m *MockService) FindByToken(arg0 context.Context, arg1 *v1.FindByTokenGroupRequest) (*v1.FindByTokenGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByToken", arg0, arg1)
+	ret0, _ := ret[0].(*v1.FindByTokenGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByToken indicates an expected call of FindByToken.
+func (mr *MockServiceMockRecorder) FindByToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByToken", reflect.TypeOf((*MockService)(nil).FindByToken), arg0, arg1)
+}
+
+// FindByUserId mocks base method.
+func (m *MockService) FindByUserId(arg0 context.Context, arg1 *v1.FindByUserIdGroupRequest) (*v1.FindByUserIdGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserId", arg0, arg1)
+	ret0, _ := ret[0].(*v1.FindByUserIdGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserId indicates an expected call of FindByUserId.
+func (mr *MockServiceMockRecorder) FindByUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserId", reflect.TypeOf((*MockService)(nil).FindByUserId), arg0, arg1)
+}
+
+// Join mocks base method.
+func (m *MockService) Join(arg0 context.Context, arg1 *v1.JoinGroupRequest) (*v1.JoinGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Join", arg0, arg1)
+	ret0, _ := ret[0].(*v1.JoinGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Join indicates an expected call of Join.
+func (mr *MockServiceMockRecorder) Join(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Join", reflect.TypeOf((*MockService)(nil).Join), arg0, arg1)
+}
+
+// Leave mocks base method.
+func (m *MockService) Leave(arg0 context.Context, arg1 *v1.LeaveGroupRequest) (*v1.LeaveGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Leave", arg0, arg1)
+	ret0, _ := ret[0].(*v1.LeaveGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Leave indicates an expected call of Leave.
+func (mr *MockServiceMockRecorder) Leave(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Leave", reflect.TypeOf((*MockService)(nil).Leave), arg0, arg1)
+}
+
+// ListJoinRequests mocks base method.
+func (m *MockService) ListJoinRequests(ctx context.Context, in *dto.ListJoinRequestsGroupRequest) (*dto.ListJoinRequestsGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJoinRequests", ctx, in)
+	ret0, _ := ret[0].(*dto.ListJoinRequestsGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJoinRequests indicates an expected call of ListJoinRequests.
+func (mr *MockServiceMockRecorder) ListJoinRequests(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJoinRequests", reflect.TypeOf((*MockService)(nil).ListJoinRequests), ctx, in)
+}
+
+// LockAll mocks base method.
+func (m *MockService) LockAll(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockAll", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockAll indicates an expected call of LockAll.
+func (mr *MockServiceMockRecorder) LockAll(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAll", reflect.TypeOf((*MockService)(nil).LockAll), ctx)
+}
+
+// RejectJoinRequest mocks base method.
+func (m *MockService) RejectJoinRequest(ctx context.Context, in *dto.RejectJoinRequestGroupRequest) (*dto.RejectJoinRequestGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectJoinRequest", ctx, in)
+	ret0, _ := ret[0].(*dto.RejectJoinRequestGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectJoinRequest indicates an expected call of RejectJoinRequest.
+func (mr *MockServiceMockRecorder) RejectJoinRequest(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectJoinRequest", reflect.TypeOf((*MockService)(nil).RejectJoinRequest), ctx, in)
+}
+
+// RotateToken mocks base method.
+func (m *MockService) RotateToken(ctx context.Context, in *dto.RotateTokenGroupRequest) (*dto.RotateTokenGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateToken", ctx, in)
+	ret0, _ := ret[0].(*dto.RotateTokenGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateToken indicates an expected call of RotateToken.
+func (mr *MockServiceMockRecorder) RotateToken(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateToken", reflect.TypeOf((*MockService)(nil).RotateToken), ctx, in)
+}
+
+// TransferLeadership mocks base method.
+func (m *MockService) TransferLeadership(ctx context.Context, in *dto.TransferLeadershipGroupRequest) (*dto.TransferLeadershipGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferLeadership", ctx, in)
+	ret0, _ := ret[0].(*dto.TransferLeadershipGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferLeadership indicates an expected call of TransferLeadership.
+func (mr *MockServiceMockRecorder) TransferLeadership(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferLeadership", reflect.TypeOf((*MockService)(nil).TransferLeadership), ctx, in)
+}
+
+// UpdateConfirm mocks base method.
+func (m *MockService) UpdateConfirm(arg0 context.Context, arg1 *v1.UpdateConfirmGroupRequest) (*v1.UpdateConfirmGroupResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfirm", arg0, arg1)
+	ret0, _ := ret[0].(*v1.UpdateConfirmGroupResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateConfirm indicates an expected call of UpdateConfirm.
+func (mr *MockServiceMockRecorder) UpdateConfirm(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfirm", reflect.TypeOf((*MockService)(nil).UpdateConfirm), arg0, arg1)
+}
+
+// mustEmbedUnimplementedGroupServiceServer mocks base method.
+func (m *MockService) mustEmbedUnimplementedGroupServiceServer() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "mustEmbedUnimplementedGroupServiceServer")
+}
+
+// mustEmbedUnimplementedGroupServiceServer indicates an expected call of mustEmbedUnimplementedGroupServiceServer.
+func (mr *MockServiceMockRecorder) mustEmbedUnimplementedGroupServiceServer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "mustEmbedUnimplementedGroupServiceServer", reflect.TypeOf((*MockService)(nil).mustEmbedUnimplementedGroupServiceServer))
+}
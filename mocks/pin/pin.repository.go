@@ -5,6 +5,7 @@
 package mock_pin
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,43 +35,43 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // DeletePin mocks base method.
-func (m *MockRepository) DeletePin(key string) error {
+func (m *MockRepository) DeletePin(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePin", key)
+	ret := m.ctrl.Call(m, "DeletePin", ctx, key)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeletePin indicates an expected call of DeletePin.
-func (mr *MockRepositoryMockRecorder) DeletePin(key interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) DeletePin(ctx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePin", reflect.TypeOf((*MockRepository)(nil).DeletePin), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePin", reflect.TypeOf((*MockRepository)(nil).DeletePin), ctx, key)
 }
 
 // GetPin mocks base method.
-func (m *MockRepository) GetPin(key string, code interface{}) error {
+func (m *MockRepository) GetPin(ctx context.Context, key string, code interface{}) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPin", key, code)
+	ret := m.ctrl.Call(m, "GetPin", ctx, key, code)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GetPin indicates an expected call of GetPin.
-func (mr *MockRepositoryMockRecorder) GetPin(key, code interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetPin(ctx, key, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPin", reflect.TypeOf((*MockRepository)(nil).GetPin), key, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPin", reflect.TypeOf((*MockRepository)(nil).GetPin), ctx, key, code)
 }
 
 // SetPin mocks base method.
-func (m *MockRepository) SetPin(key string, code interface{}) error {
+func (m *MockRepository) SetPin(ctx context.Context, key string, code interface{}) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetPin", key, code)
+	ret := m.ctrl.Call(m, "SetPin", ctx, key, code)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetPin indicates an expected call of SetPin.
-func (mr *MockRepositoryMockRecorder) SetPin(key, code interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) SetPin(ctx, key, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPin", reflect.TypeOf((*MockRepository)(nil).SetPin), key, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPin", reflect.TypeOf((*MockRepository)(nil).SetPin), ctx, key, code)
 }
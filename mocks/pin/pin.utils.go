@@ -47,3 +47,33 @@ func (mr *MockUtilsMockRecorder) GeneratePIN() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeneratePIN", reflect.TypeOf((*MockUtils)(nil).GeneratePIN))
 }
+
+// GenerateSecret mocks base method.
+func (m *MockUtils) GenerateSecret() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateSecret")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateSecret indicates an expected call of GenerateSecret.
+func (mr *MockUtilsMockRecorder) GenerateSecret() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateSecret", reflect.TypeOf((*MockUtils)(nil).GenerateSecret))
+}
+
+// TimePIN mocks base method.
+func (m *MockUtils) TimePIN(secret string, window int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TimePIN", secret, window)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TimePIN indicates an expected call of TimePIN.
+func (mr *MockUtilsMockRecorder) TimePIN(secret, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TimePIN", reflect.TypeOf((*MockUtils)(nil).TimePIN), secret, window)
+}
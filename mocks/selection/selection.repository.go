@@ -9,6 +9,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/isd-sgcu/rpkm67-model/model"
+	gorm "gorm.io/gorm"
 )
 
 // MockRepository is a mock of Repository interface.
@@ -49,32 +50,61 @@ func (mr *MockRepositoryMockRecorder) CountByBaanId() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByBaanId", reflect.TypeOf((*MockRepository)(nil).CountByBaanId))
 }
 
-// Create mocks base method.
-func (m *MockRepository) Create(user *model.Selection) error {
+// CountFirstChoiceMembersByBaanId mocks base method.
+func (m *MockRepository) CountFirstChoiceMembersByBaanId() (map[string]int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", user)
+	ret := m.ctrl.Call(m, "CountFirstChoiceMembersByBaanId")
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFirstChoiceMembersByBaanId indicates an expected call of CountFirstChoiceMembersByBaanId.
+func (mr *MockRepositoryMockRecorder) CountFirstChoiceMembersByBaanId() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFirstChoiceMembersByBaanId", reflect.TypeOf((*MockRepository)(nil).CountFirstChoiceMembersByBaanId))
+}
+
+// CreateTX mocks base method.
+func (m *MockRepository) CreateTX(tx *gorm.DB, selection *model.Selection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTX", tx, selection)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Create indicates an expected call of Create.
-func (mr *MockRepositoryMockRecorder) Create(user interface{}) *gomock.Call {
+// CreateTX indicates an expected call of CreateTX.
+func (mr *MockRepositoryMockRecorder) CreateTX(tx, selection interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTX", reflect.TypeOf((*MockRepository)(nil).CreateTX), tx, selection)
 }
 
-// Delete mocks base method.
-func (m *MockRepository) Delete(groupId, baanId string) error {
+// DeleteByGroupIdTX mocks base method.
+func (m *MockRepository) DeleteByGroupIdTX(tx *gorm.DB, groupId string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", groupId, baanId)
+	ret := m.ctrl.Call(m, "DeleteByGroupIdTX", tx, groupId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockRepositoryMockRecorder) Delete(groupId, baanId interface{}) *gomock.Call {
+// DeleteByGroupIdTX indicates an expected call of DeleteByGroupIdTX.
+func (mr *MockRepositoryMockRecorder) DeleteByGroupIdTX(tx, groupId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), groupId, baanId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByGroupIdTX", reflect.TypeOf((*MockRepository)(nil).DeleteByGroupIdTX), tx, groupId)
+}
+
+// DeleteTX mocks base method.
+func (m *MockRepository) DeleteTX(tx *gorm.DB, groupId, baanId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTX", tx, groupId, baanId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTX indicates an expected call of DeleteTX.
+func (mr *MockRepositoryMockRecorder) DeleteTX(tx, groupId, baanId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTX", reflect.TypeOf((*MockRepository)(nil).DeleteTX), tx, groupId, baanId)
 }
 
 // FindByGroupId mocks base method.
@@ -91,44 +121,86 @@ func (mr *MockRepositoryMockRecorder) FindByGroupId(groupId, selections interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByGroupId", reflect.TypeOf((*MockRepository)(nil).FindByGroupId), groupId, selections)
 }
 
-// UpdateExistBaanExistOrder mocks base method.
-func (m *MockRepository) UpdateExistBaanExistOrder(updateSelection *model.Selection) error {
+// FindByGroupIdTX mocks base method.
+func (m *MockRepository) FindByGroupIdTX(tx *gorm.DB, groupId string, selections *[]model.Selection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByGroupIdTX", tx, groupId, selections)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindByGroupIdTX indicates an expected call of FindByGroupIdTX.
+func (mr *MockRepositoryMockRecorder) FindByGroupIdTX(tx, groupId, selections interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByGroupIdTX", reflect.TypeOf((*MockRepository)(nil).FindByGroupIdTX), tx, groupId, selections)
+}
+
+// ReplaceByGroupIdTX mocks base method.
+func (m *MockRepository) ReplaceByGroupIdTX(tx *gorm.DB, groupId string, selections []*model.Selection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceByGroupIdTX", tx, groupId, selections)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceByGroupIdTX indicates an expected call of ReplaceByGroupIdTX.
+func (mr *MockRepositoryMockRecorder) ReplaceByGroupIdTX(tx, groupId, selections interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceByGroupIdTX", reflect.TypeOf((*MockRepository)(nil).ReplaceByGroupIdTX), tx, groupId, selections)
+}
+
+// UpdateExistBaanExistOrderTX mocks base method.
+func (m *MockRepository) UpdateExistBaanExistOrderTX(tx *gorm.DB, updateSelection *model.Selection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExistBaanExistOrderTX", tx, updateSelection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateExistBaanExistOrderTX indicates an expected call of UpdateExistBaanExistOrderTX.
+func (mr *MockRepositoryMockRecorder) UpdateExistBaanExistOrderTX(tx, updateSelection interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExistBaanExistOrderTX", reflect.TypeOf((*MockRepository)(nil).UpdateExistBaanExistOrderTX), tx, updateSelection)
+}
+
+// UpdateExistBaanNewOrderTX mocks base method.
+func (m *MockRepository) UpdateExistBaanNewOrderTX(tx *gorm.DB, updateSelection *model.Selection) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateExistBaanExistOrder", updateSelection)
+	ret := m.ctrl.Call(m, "UpdateExistBaanNewOrderTX", tx, updateSelection)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateExistBaanExistOrder indicates an expected call of UpdateExistBaanExistOrder.
-func (mr *MockRepositoryMockRecorder) UpdateExistBaanExistOrder(updateSelection interface{}) *gomock.Call {
+// UpdateExistBaanNewOrderTX indicates an expected call of UpdateExistBaanNewOrderTX.
+func (mr *MockRepositoryMockRecorder) UpdateExistBaanNewOrderTX(tx, updateSelection interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExistBaanExistOrder", reflect.TypeOf((*MockRepository)(nil).UpdateExistBaanExistOrder), updateSelection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExistBaanNewOrderTX", reflect.TypeOf((*MockRepository)(nil).UpdateExistBaanNewOrderTX), tx, updateSelection)
 }
 
-// UpdateExistBaanNewOrder mocks base method.
-func (m *MockRepository) UpdateExistBaanNewOrder(updateSelection *model.Selection) error {
+// UpdateNewBaanExistOrderTX mocks base method.
+func (m *MockRepository) UpdateNewBaanExistOrderTX(tx *gorm.DB, updateSelection *model.Selection) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateExistBaanNewOrder", updateSelection)
+	ret := m.ctrl.Call(m, "UpdateNewBaanExistOrderTX", tx, updateSelection)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateExistBaanNewOrder indicates an expected call of UpdateExistBaanNewOrder.
-func (mr *MockRepositoryMockRecorder) UpdateExistBaanNewOrder(updateSelection interface{}) *gomock.Call {
+// UpdateNewBaanExistOrderTX indicates an expected call of UpdateNewBaanExistOrderTX.
+func (mr *MockRepositoryMockRecorder) UpdateNewBaanExistOrderTX(tx, updateSelection interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExistBaanNewOrder", reflect.TypeOf((*MockRepository)(nil).UpdateExistBaanNewOrder), updateSelection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNewBaanExistOrderTX", reflect.TypeOf((*MockRepository)(nil).UpdateNewBaanExistOrderTX), tx, updateSelection)
 }
 
-// UpdateNewBaanExistOrder mocks base method.
-func (m *MockRepository) UpdateNewBaanExistOrder(updateSelection *model.Selection) error {
+// WithTransaction mocks base method.
+func (m *MockRepository) WithTransaction(txFunc func(*gorm.DB) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateNewBaanExistOrder", updateSelection)
+	ret := m.ctrl.Call(m, "WithTransaction", txFunc)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateNewBaanExistOrder indicates an expected call of UpdateNewBaanExistOrder.
-func (mr *MockRepositoryMockRecorder) UpdateNewBaanExistOrder(updateSelection interface{}) *gomock.Call {
+// WithTransaction indicates an expected call of WithTransaction.
+func (mr *MockRepositoryMockRecorder) WithTransaction(txFunc interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNewBaanExistOrder", reflect.TypeOf((*MockRepository)(nil).UpdateNewBaanExistOrder), updateSelection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockRepository)(nil).WithTransaction), txFunc)
 }
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	dto "github.com/isd-sgcu/rpkm67-backend/internal/dto"
 	v1 "github.com/isd-sgcu/rpkm67-go-proto/rpkm67/backend/selection/v1"
 )
 
@@ -50,6 +51,21 @@ func (mr *MockServiceMockRecorder) CountByBaanId(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByBaanId", reflect.TypeOf((*MockService)(nil).CountByBaanId), arg0, arg1)
 }
 
+// CountCapacityByBaanId mocks base method.
+func (m *MockService) CountCapacityByBaanId(ctx context.Context, in *dto.CountCapacityByBaanIdRequest) (*dto.CountCapacityByBaanIdResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCapacityByBaanId", ctx, in)
+	ret0, _ := ret[0].(*dto.CountCapacityByBaanIdResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCapacityByBaanId indicates an expected call of CountCapacityByBaanId.
+func (mr *MockServiceMockRecorder) CountCapacityByBaanId(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCapacityByBaanId", reflect.TypeOf((*MockService)(nil).CountCapacityByBaanId), ctx, in)
+}
+
 // Create mocks base method.
 func (m *MockService) Create(arg0 context.Context, arg1 *v1.CreateSelectionRequest) (*v1.CreateSelectionResponse, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +111,21 @@ func (mr *MockServiceMockRecorder) FindByGroupId(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByGroupId", reflect.TypeOf((*MockService)(nil).FindByGroupId), arg0, arg1)
 }
 
+// ReplaceAll mocks base method.
+func (m *MockService) ReplaceAll(ctx context.Context, in *dto.ReplaceAllSelectionRequest) (*dto.ReplaceAllSelectionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceAll", ctx, in)
+	ret0, _ := ret[0].(*dto.ReplaceAllSelectionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceAll indicates an expected call of ReplaceAll.
+func (mr *MockServiceMockRecorder) ReplaceAll(ctx, in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceAll", reflect.TypeOf((*MockService)(nil).ReplaceAll), ctx, in)
+}
+
 // Update mocks base method.
 func (m *MockService) Update(arg0 context.Context, arg1 *v1.UpdateSelectionRequest) (*v1.UpdateSelectionResponse, error) {
 	m.ctrl.T.Helper()
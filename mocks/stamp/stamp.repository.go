@@ -34,6 +34,20 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CreateAnswer mocks base method.
+func (m *MockRepository) CreateAnswer(answer *model.Answer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAnswer", answer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAnswer indicates an expected call of CreateAnswer.
+func (mr *MockRepositoryMockRecorder) CreateAnswer(answer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAnswer", reflect.TypeOf((*MockRepository)(nil).CreateAnswer), answer)
+}
+
 // FindByUserId mocks base method.
 func (m *MockRepository) FindByUserId(userId string, stamp *model.Stamp) error {
 	m.ctrl.T.Helper()
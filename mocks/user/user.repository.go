@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/user/user.repository.go
+
+// Package mock_user is a generated GoMock package.
+package mock_user
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	model "github.com/isd-sgcu/rpkm67-model/model"
+	gorm "gorm.io/gorm"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AssignGroupTX mocks base method.
+func (m *MockRepository) AssignGroupTX(tx *gorm.DB, id string, groupID *uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignGroupTX", tx, id, groupID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignGroupTX indicates an expected call of AssignGroupTX.
+func (mr *MockRepositoryMockRecorder) AssignGroupTX(tx, id, groupID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignGroupTX", reflect.TypeOf((*MockRepository)(nil).AssignGroupTX), tx, id, groupID)
+}
+
+// FindOne mocks base method.
+func (m *MockRepository) FindOne(id string, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOne", id, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindOne indicates an expected call of FindOne.
+func (mr *MockRepositoryMockRecorder) FindOne(id, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOne", reflect.TypeOf((*MockRepository)(nil).FindOne), id, user)
+}
+
+// FindOneForUpdateTX mocks base method.
+func (m *MockRepository) FindOneForUpdateTX(tx *gorm.DB, id string, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOneForUpdateTX", tx, id, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindOneForUpdateTX indicates an expected call of FindOneForUpdateTX.
+func (mr *MockRepositoryMockRecorder) FindOneForUpdateTX(tx, id, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOneForUpdateTX", reflect.TypeOf((*MockRepository)(nil).FindOneForUpdateTX), tx, id, user)
+}
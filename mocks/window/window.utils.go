@@ -0,0 +1,89 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/window/window.utils.go
+
+// Package mock_window is a generated GoMock package.
+package mock_window
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWindow is a mock of Window interface.
+type MockWindow struct {
+	ctrl     *gomock.Controller
+	recorder *MockWindowMockRecorder
+}
+
+// MockWindowMockRecorder is the mock recorder for MockWindow.
+type MockWindowMockRecorder struct {
+	mock *MockWindow
+}
+
+// NewMockWindow creates a new mock instance.
+func NewMockWindow(ctrl *gomock.Controller) *MockWindow {
+	mock := &MockWindow{ctrl: ctrl}
+	mock.recorder = &MockWindowMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWindow) EXPECT() *MockWindowMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockWindow) Check() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockWindowMockRecorder) Check() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockWindow)(nil).Check))
+}
+
+// CloseAt mocks base method.
+func (m *MockWindow) CloseAt() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAt")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// CloseAt indicates an expected call of CloseAt.
+func (mr *MockWindowMockRecorder) CloseAt() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAt", reflect.TypeOf((*MockWindow)(nil).CloseAt))
+}
+
+// IsClosed mocks base method.
+func (m *MockWindow) IsClosed() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsClosed")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsClosed indicates an expected call of IsClosed.
+func (mr *MockWindowMockRecorder) IsClosed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsClosed", reflect.TypeOf((*MockWindow)(nil).IsClosed))
+}
+
+// SetOverride mocks base method.
+func (m *MockWindow) SetOverride(override bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetOverride", override)
+}
+
+// SetOverride indicates an expected call of SetOverride.
+func (mr *MockWindowMockRecorder) SetOverride(override interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOverride", reflect.TypeOf((*MockWindow)(nil).SetOverride), override)
+}